@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"log"
+	"net/url"
 	"os"
 	"strings"
 
@@ -10,45 +13,149 @@ import (
 )
 
 const usage = `Usage:
-	crawler [-v] <url>
+	crawler [flags] <url>
+
+Flags:
 `
 
 func main() {
-	flag.BoolVar(&crawler.Verbose, "v", false, "verbose logging")
+	verbose := flag.Bool("v", false, "verbose logging")
+	format := flag.String("format", "text", "output format: "+formatNames())
+	o := &output{}
+	flag.StringVar(&o.out, "o", "", "output file, or directory for formats writing several files; stdout if empty")
+	flag.IntVar(&o.diagram.ClusterDepth, "cluster-depth", 1, "dot, mermaid: group pages by this many leading path segments, 0 for no groups")
+	collapse := flag.String("collapse", "", "dot, mermaid: comma separated pages whose inbound links are hidden, e.g. /,/blog")
+	flag.IntVar(&o.diagram.CollapseFanIn, "collapse-fan-in", 0, "dot, mermaid: hide inbound links of pages linked from at least this many pages, 0 to keep all")
+	concurrency := flag.Int("c", crawler.DefaultConcurrency, "maximum number of concurrent requests")
+	userAgent := flag.String("user-agent", crawler.DefaultUserAgent, "user agent sent with requests and matched against robots.txt")
+	ignoreRobots := flag.Bool("ignore-robots", false, "don't fetch or obey robots.txt, only use on sites you own")
+	rps := flag.Float64("rps", 0, "maximum requests per second to each host, 0 for no limit")
+	delay := flag.Duration("delay", 0, "minimum delay between requests to the same host, e.g. 500ms")
+	hostConcurrency := flag.Int("host-c", 0, "maximum number of concurrent requests to each host, 0 for no limit")
+	retries := flag.Int("retries", crawler.DefaultRetries, "times to retry transient network errors, 429 and 5xx responses")
+	follow := flag.String("follow", strings.Join(crawler.DefaultFollow, ","), "comma separated html elements whose links are followed, from a, area, link, iframe, frame, form, img, script and meta")
+	dropQuery := flag.Bool("drop-query", false, "ignore query strings, so /search?q=a and /search?q=b are one page")
+	keepParams := flag.String("keep-params", "", "comma separated query params to keep, dropping all others, globs allowed, e.g. page,q")
+	dropParams := flag.String("drop-params", "", "comma separated query params to drop, globs allowed, e.g. utm_*,sessionid")
+	trimSlash := flag.Bool("trim-trailing-slash", false, "treat /a/ and /a as one page")
+	unifyScheme := flag.Bool("unify-scheme", false, "treat http and https urls as one page, using the scheme of the seed url")
+	subdomains := flag.Bool("subdomains", false, "also crawl subdomains of the seed's host and of -hosts, ignoring a leading www.")
+	hosts := flag.String("hosts", "", "comma separated hosts to crawl as well as the seed's host")
+	pathPrefixes := flag.String("path-prefix", "", "comma separated path prefixes to restrict the crawl to, e.g. /blog/")
+	var include, exclude patterns
+	flag.Var(&include, "include", "only crawl urls matching this glob, or regular expression starting with re:, may be repeated")
+	flag.Var(&exclude, "exclude", "don't crawl urls matching this glob, or regular expression starting with re:, may be repeated")
+	maxDepth := flag.Int("max-depth", 0, "don't crawl pages more than this many clicks from the seed, 0 for no limit")
+	maxPages := flag.Int("max-pages", 0, "stop fetching pages after this many, 0 for no limit")
+	maxPrefixPages := flag.Int("max-prefix-pages", 0, "fetch at most this many pages under each path prefix, 0 for no limit")
+	prefixSegments := flag.Int("prefix-segments", 1, "path segments making up a prefix for -max-prefix-pages, e.g. 1 for /events")
+	failFast := flag.Bool("fail-fast", false, "stop the whole crawl as soon as one page fails")
+	timeout := flag.Duration("timeout", 0, "stop crawling after this long and print what was found, e.g. 30s")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 	if flag.NArg() < 1 {
-		fmt.Println(usage)
+		flag.Usage()
+		os.Exit(1)
+	}
+	write := writers[*format]
+	if write == nil {
+		fmt.Fprintf(os.Stderr, "unknown format %q\n", *format)
 		os.Exit(1)
 	}
+	o.diagram.Collapse = split(*collapse)
 
 	u := flag.Arg(0)
-	page, err := crawler.Crawl(u)
+	normalizer := crawler.Normalizer{
+		DropQuery:         *dropQuery,
+		KeepParams:        split(*keepParams),
+		DropParams:        split(*dropParams),
+		TrimTrailingSlash: *trimSlash,
+	}
+	if *unifyScheme {
+		if seed, err := url.Parse(u); err == nil {
+			normalizer.Scheme = strings.ToLower(seed.Scheme)
+		}
+	}
+	scope, err := crawler.Scope{
+		Subdomains:   *subdomains,
+		Hosts:        split(*hosts),
+		PathPrefixes: split(*pathPrefixes),
+		Include:      include,
+		Exclude:      exclude,
+	}.Func()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "failed to crawl %s: %v", u, err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	opts := []crawler.Option{
+		crawler.WithConcurrency(*concurrency),
+		crawler.WithUserAgent(*userAgent),
+		crawler.WithIgnoreRobots(*ignoreRobots),
+		crawler.WithRateLimit(*rps),
+		crawler.WithDelay(*delay),
+		crawler.WithHostConcurrency(*hostConcurrency),
+		crawler.WithRetries(*retries),
+		crawler.WithFailFast(*failFast),
+		crawler.WithFollow(strings.Split(*follow, ",")...),
+		crawler.WithNormalizer(normalizer),
+		crawler.WithScope(scope),
+		crawler.WithMaxDepth(*maxDepth),
+		crawler.WithMaxPages(*maxPages),
+		crawler.WithMaxPagesPerPrefix(*maxPrefixPages, *prefixSegments),
+	}
+	if *verbose {
+		opts = append(opts, crawler.WithLogger(log.New(os.Stderr, "", 0)))
+	}
+	if stream := streamers[*format]; stream != nil {
+		opt, err := stream(o)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write %s: %v\n", *format, err)
+			os.Exit(2)
+		}
+		opts = append(opts, opt)
+	}
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	g, err := crawler.New(opts...).CrawlContext(ctx, u)
+	pageErrs, _ := err.(crawler.Errors)
+	if err == context.DeadlineExceeded && g != nil {
+		fmt.Fprintf(os.Stderr, "crawl of %s stopped after %v, showing partial result\n", u, *timeout)
+	} else if err != nil && pageErrs == nil {
+		fmt.Fprintf(os.Stderr, "failed to crawl %s: %v\n", u, err)
+		os.Exit(2)
+	}
+
+	if err := write(g, o); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write %s: %v\n", *format, err)
 		os.Exit(2)
 	}
 
-	print(page, 0)
+	if pageErrs != nil {
+		fmt.Fprintln(os.Stderr, pageErrs)
+	}
 }
 
-var printed = make(map[string]bool)
+// patterns is a flag that may be repeated, as patterns can contain commas
+type patterns []string
 
-func print(p *crawler.Page, indent int) {
-	fmt.Print(strings.Repeat(" ", indent))
-	if printed[p.Info.URI] {
-		fmt.Printf("(showed) %s \"%s\"\n", p.Info.URI, p.Info.Description)
-		return
-	}
-	fmt.Printf("%s \"%s\"\n", p.Info.URI, p.Info.Description)
-	printed[p.Info.URI] = true
+func (p *patterns) String() string { return strings.Join(*p, " ") }
 
-	// skip dup on the same level
-	unique := make(map[string]bool)
-	for _, p := range p.Links {
-		if unique[p.Info.URI] {
-			continue
-		}
-		unique[p.Info.URI] = true
-		print(p, indent+2)
+func (p *patterns) Set(s string) error {
+	*p = append(*p, s)
+	return nil
+}
+
+// split splits a comma separated flag value, empty for an empty value
+func split(s string) []string {
+	if s == "" {
+		return nil
 	}
+	return strings.Split(s, ",")
 }
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jackielii/crawler"
+)
+
+// output holds the flags controlling the output
+type output struct {
+	out     string // -o, empty for stdout
+	diagram crawler.DiagramOptions
+
+	stream    io.WriteCloser // where streaming formats write while crawling
+	streamErr error
+}
+
+// streamers set up the formats written while crawling. They return the
+// crawler option doing the writing; the matching writer finishes the output
+var streamers = map[string]func(o *output) (crawler.Option, error){
+	"jsonl": streamJSONL,
+}
+
+// writers write the graph in each -format
+var writers = map[string]func(g *crawler.Graph, o *output) error{
+	"text":    writeText,
+	"json":    writeJSON,
+	"sitemap": writeSitemap,
+	"dot":     writeDOT,
+	"mermaid": writeMermaid,
+	"graphml": writeGraphML,
+	"gexf":    writeGEXF,
+	"csv":     writeCSV,
+	"jsonl":   closeStream,
+	"html":    writeHTML,
+}
+
+func formatNames() string {
+	var names []string
+	for name := range writers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// nopCloser keeps stdout open
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+// create opens the file out for writing, or stdout if out is empty
+func create(out string) (io.WriteCloser, error) {
+	if out == "" {
+		return nopCloser{os.Stdout}, nil
+	}
+	return os.Create(out)
+}
+
+// writeFile writes to the file out, or stdout if out is empty, with write
+func writeFile(out string, write func(w io.Writer) error) error {
+	f, err := create(out)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeText(g *crawler.Graph, o *output) error {
+	return writeFile(o.out, func(w io.Writer) error {
+		printTree(w, g)
+		return nil
+	})
+}
+
+func writeJSON(g *crawler.Graph, o *output) error {
+	return writeFile(o.out, func(w io.Writer) error {
+		b, err := json.MarshalIndent(g, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(b, '\n'))
+		return err
+	})
+}
+
+func writeDOT(g *crawler.Graph, o *output) error {
+	return writeFile(o.out, func(w io.Writer) error {
+		return crawler.WriteDOT(w, g, o.diagram)
+	})
+}
+
+func writeMermaid(g *crawler.Graph, o *output) error {
+	return writeFile(o.out, func(w io.Writer) error {
+		return crawler.WriteMermaid(w, g, o.diagram)
+	})
+}
+
+func writeGraphML(g *crawler.Graph, o *output) error {
+	return writeFile(o.out, func(w io.Writer) error {
+		return crawler.WriteGraphML(w, g)
+	})
+}
+
+func writeGEXF(g *crawler.Graph, o *output) error {
+	return writeFile(o.out, func(w io.Writer) error {
+		return crawler.WriteGEXF(w, g)
+	})
+}
+
+func writeHTML(g *crawler.Graph, o *output) error {
+	return writeFile(o.out, func(w io.Writer) error {
+		return crawler.WriteHTMLReport(w, g)
+	})
+}
+
+// streamJSONL writes a JSON line for each page as soon as it's been visited
+func streamJSONL(o *output) (crawler.Option, error) {
+	f, err := create(o.out)
+	if err != nil {
+		return nil, err
+	}
+	o.stream = f
+	enc := json.NewEncoder(f)
+	return crawler.WithOnPage(func(p *crawler.Page, links []*crawler.Link) {
+		if err := enc.Encode(crawler.NewJSONLine(p, links)); err != nil && o.streamErr == nil {
+			o.streamErr = err
+		}
+	}), nil
+}
+
+func closeStream(g *crawler.Graph, o *output) error {
+	err := o.stream.Close()
+	if o.streamErr != nil {
+		return o.streamErr
+	}
+	return err
+}
+
+// writeCSV writes pages.csv and links.csv into the directory -o, or the
+// current directory
+func writeCSV(g *crawler.Graph, o *output) error {
+	dir := o.out
+	if dir == "" {
+		dir = "."
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	err := writeFile(filepath.Join(dir, "pages.csv"), func(w io.Writer) error {
+		return crawler.WritePagesCSV(w, g)
+	})
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(dir, "links.csv"), func(w io.Writer) error {
+		return crawler.WriteLinksCSV(w, g)
+	})
+}
+
+// writeSitemap writes one sitemap, or if there are too many pages for one,
+// numbered sitemaps and a sitemap.xml index into the directory -o. The index
+// expects the sitemaps to be served from the site root
+func writeSitemap(g *crawler.Graph, o *output) error {
+	out := o.out
+	chunks := crawler.SitemapPages(g)
+	if len(chunks) <= 1 {
+		var pages []*crawler.Page
+		if len(chunks) == 1 {
+			pages = chunks[0]
+		}
+		return writeFile(out, func(w io.Writer) error {
+			return crawler.WriteSitemap(w, pages)
+		})
+	}
+
+	if out == "" {
+		return fmt.Errorf("%d sitemaps are needed, use -o to give a directory for them", len(chunks))
+	}
+	if err := os.MkdirAll(out, 0755); err != nil {
+		return err
+	}
+	root, err := url.Parse(g.Root.URL)
+	if err != nil {
+		return err
+	}
+	var locs []string
+	for i, pages := range chunks {
+		name := fmt.Sprintf("sitemap-%d.xml", i+1)
+		err := writeFile(filepath.Join(out, name), func(w io.Writer) error {
+			return crawler.WriteSitemap(w, pages)
+		})
+		if err != nil {
+			return err
+		}
+		locs = append(locs, root.ResolveReference(&url.URL{Path: "/" + name}).String())
+	}
+	return writeFile(filepath.Join(out, "sitemap.xml"), func(w io.Writer) error {
+		return crawler.WriteSitemapIndex(w, locs)
+	})
+}
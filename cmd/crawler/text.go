@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/jackielii/crawler"
+)
+
+// printTree prints the site map as an indented tree from the root page.
+// Each page is expanded under the page it was first reached from by fewest
+// clicks, so it's indented by its depth. Elsewhere it's marked as (showed)
+func printTree(w io.Writer, g *crawler.Graph) {
+	root, _ := url.Parse(g.Root.URL)
+
+	// breadth first, so that pages hang under a page closest to the root
+	parent := map[*crawler.Page]*crawler.Page{g.Root: nil}
+	queue := []*crawler.Page{g.Root}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		for _, l := range g.Outbound(p) {
+			if _, ok := parent[l.To]; !ok {
+				parent[l.To] = p
+				queue = append(queue, l.To)
+			}
+		}
+	}
+
+	printed := make(map[*crawler.Page]bool)
+	var print func(from, p *crawler.Page, text string, indent int)
+	print = func(from, p *crawler.Page, text string, indent int) {
+		fmt.Fprint(w, strings.Repeat(" ", indent))
+		if printed[p] || parent[p] != from {
+			fmt.Fprintf(w, "(showed) %s \"%s\"\n", display(root, p), text)
+			return
+		}
+		fmt.Fprintf(w, "%s \"%s\"%s\n", display(root, p), text, status(p))
+		printed[p] = true
+		for _, d := range p.Diagnostics {
+			fmt.Fprintf(w, "%s! malformed link %q: %s\n", strings.Repeat(" ", indent+2), d.Raw, d.Reason)
+		}
+
+		// skip dup on the same level
+		unique := make(map[*crawler.Page]bool)
+		for _, l := range g.Outbound(p) {
+			if unique[l.To] {
+				continue
+			}
+			unique[l.To] = true
+			print(p, l.To, l.Text, indent+2)
+		}
+	}
+	print(nil, g.Root, g.Root.URL, 0)
+}
+
+// display shortens the url of p to its path if it's on the root's host
+func display(root *url.URL, p *crawler.Page) string {
+	u, err := url.Parse(p.URL)
+	if err != nil || u.Host != root.Host {
+		return p.URL
+	}
+	return u.RequestURI()
+}
+
+// status describes pages that weren't crawled successfully
+func status(p *crawler.Page) string {
+	switch {
+	case p.Err != nil:
+		return fmt.Sprintf(" (error: %v)", p.Err)
+	case p.NotCrawled != "":
+		return fmt.Sprintf(" (%s)", p.NotCrawled)
+	case p.StatusCode != 0 && p.StatusCode != 200:
+		return fmt.Sprintf(" (%d)", p.StatusCode)
+	}
+	return ""
+}
@@ -2,225 +2,598 @@ package crawler
 
 import (
 	"context"
-	"fmt"
 	"io"
+	"log"
+	"mime"
 	"net/http"
 	"net/url"
-	"strings"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
-	"golang.org/x/net/html"
 )
 
-// Verbose is the flag toggle verbose logging
-var Verbose bool
+// notFollowed is the NotCrawled reason of pages only linked from elements
+// that aren't followed
+const notFollowed = "not followed"
 
-// QueueSize is the size of the queue to fetch urls concurrently
-var QueueSize = 100
-var globalTaskQueue = make(chan struct{}, QueueSize)
+// DefaultConcurrency is the default number of urls fetched concurrently
+const DefaultConcurrency = 100
 
-// Page represents a web page
-type Page struct {
-	Info  URL
-	Links []*Page // links within the page of the link
+// Crawler crawls a site. Each Crawler holds its own settings, and each call
+// to Crawl has its own concurrency limit, so crawls don't affect each other.
+type Crawler struct {
+	client       *http.Client
+	fetcher      Fetcher
+	concurrency  int
+	logger       *log.Logger
+	scope        ScopeFunc
+	userAgent    string
+	ignoreRobots bool
+	failFast     bool
+	follow       map[string]bool // elements whose links are followed
+	normalizer   Normalizer
+	onPage       func(p *Page, links []*Link)
+
+	// per host politeness
+	rps             float64
+	delay           time.Duration
+	hostConcurrency int
+
+	retries    int
+	backoff    time.Duration
+	maxBackoff time.Duration
+
+	maxDepth       int
+	maxPages       int
+	maxPrefixPages int
+	prefixSegments int
 }
 
-// URL represents the page's metadata
-type URL struct {
-	URI         string
-	Description string
+// Option configures a Crawler
+type Option func(*Crawler)
+
+// WithHTTPClient sets the http client used to fetch pages
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Crawler) { c.client = client }
 }
 
-// parse reads from r and returns all think from r
-func parse(u *url.URL, r io.Reader) ([]URL, error) {
-	doc, err := html.Parse(r)
-	if err != nil {
-		return nil, errors.Wrap(err, "unable to parse html")
-	}
-	if !strings.HasSuffix(u.Path, "/") {
-		u.Path += "/"
-	}
-
-	var links []URL
-	var walk func(*html.Node)
-	walk = func(n *html.Node) {
-		if n.Type == html.ElementNode && n.Data == "a" {
-			link := URL{}
-			for _, a := range n.Attr {
-				if a.Key == "href" {
-					u1, err := url.Parse(a.Val)
-					if err != nil {
-						panic(err)
-					}
+// WithFetcher sets the Fetcher used to fetch pages, replacing the default
+// http based one. The http client and user agent options don't apply to it
+func WithFetcher(f Fetcher) Option {
+	return func(c *Crawler) { c.fetcher = f }
+}
 
-					if u1.Hostname() != "" && u1.Hostname() != u.Hostname() {
-						continue
-					}
+// WithConcurrency sets the maximum number of urls fetched concurrently
+func WithConcurrency(n int) Option {
+	return func(c *Crawler) { c.concurrency = n }
+}
 
-					link.URI = u.ResolveReference(u1).Path
-					if n.FirstChild != nil {
-						link.Description = sanitise(n.FirstChild.Data)
-					}
-					break
-				}
-			}
-			// only add internal relative links
-			if link.URI != "" &&
-				!strings.HasPrefix(link.URI, "http://") &&
-				!strings.HasPrefix(link.URI, "https://") {
-				links = append(links, link)
-			}
-		}
-		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			walk(c)
+// WithLogger sets the logger for verbose logging. Nothing is logged if l is nil
+func WithLogger(l *log.Logger) Option {
+	return func(c *Crawler) { c.logger = l }
+}
+
+// WithScope sets the rule deciding which links are followed
+func WithScope(scope ScopeFunc) Option {
+	return func(c *Crawler) { c.scope = scope }
+}
+
+// WithUserAgent sets the user agent sent with requests and used to pick the
+// robots.txt rules
+func WithUserAgent(ua string) Option {
+	return func(c *Crawler) { c.userAgent = ua }
+}
+
+// WithIgnoreRobots turns off robots.txt handling. Only use it on sites you
+// own, e.g. internal sites
+func WithIgnoreRobots(ignore bool) Option {
+	return func(c *Crawler) { c.ignoreRobots = ignore }
+}
+
+// WithRateLimit limits the requests per second made to each host. Zero means
+// no limit
+func WithRateLimit(rps float64) Option {
+	return func(c *Crawler) { c.rps = rps }
+}
+
+// WithDelay sets the minimum delay between the starts of two requests to the
+// same host. A robots.txt Crawl-delay takes precedence if it's longer
+func WithDelay(d time.Duration) Option {
+	return func(c *Crawler) { c.delay = d }
+}
+
+// WithHostConcurrency limits the number of concurrent requests made to each
+// host. Zero means only the overall concurrency limit applies
+func WithHostConcurrency(n int) Option {
+	return func(c *Crawler) { c.hostConcurrency = n }
+}
+
+// WithRetries sets how many times a transient network error, 429 or 5xx response is
+// retried
+func WithRetries(n int) Option {
+	return func(c *Crawler) { c.retries = n }
+}
+
+// WithBackoff sets the wait before the first retry, doubled for every further
+// retry up to max
+func WithBackoff(base, max time.Duration) Option {
+	return func(c *Crawler) { c.backoff, c.maxBackoff = base, max }
+}
+
+// WithFailFast makes the crawl stop and return only the error as soon as a
+// page fails. By default failed pages are recorded and the crawl carries on
+func WithFailFast(failFast bool) Option {
+	return func(c *Crawler) { c.failFast = failFast }
+}
+
+// WithOnPage sets a handler called with each page, and the links found on
+// it, as soon as the page has been visited. Calls are made one at a time, so
+// the handler doesn't need to synchronise; a slow handler slows the crawl
+func WithOnPage(handler func(p *Page, links []*Link)) Option {
+	return func(c *Crawler) { c.onPage = handler }
+}
+
+// WithFollow sets the html elements whose links are followed, replacing
+// DefaultFollow. Links can be found in a, area, link, iframe, frame, form,
+// img, script and meta (refresh) elements. Other links are still added to the
+// graph, to pages that aren't fetched
+func WithFollow(elements ...string) Option {
+	return func(c *Crawler) {
+		c.follow = make(map[string]bool)
+		for _, e := range elements {
+			c.follow[e] = true
 		}
 	}
-	walk(doc)
-	return links, nil
+}
+
+// New returns a Crawler configured by opts
+func New(opts ...Option) *Crawler {
+	c := &Crawler{
+		concurrency: DefaultConcurrency,
+		scope:       SameHost,
+		userAgent:   DefaultUserAgent,
+		retries:     DefaultRetries,
+		backoff:     DefaultBackoff,
+		maxBackoff:  DefaultMaxBackoff,
+	}
+	WithFollow(DefaultFollow...)(c)
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.concurrency < 1 {
+		c.concurrency = 1
+	}
+	if c.fetcher == nil {
+		c.fetcher = &HTTPFetcher{Client: c.client, UserAgent: c.userAgent}
+	}
+	return c
+}
+
+// run holds the state of one call to CrawlContext
+type run struct {
+	*Crawler
+	taskQueue chan struct{} // limits concurrent requests
+	hosts     *hostLimits
+	limits    *limits
+
+	robotsLock sync.Mutex
+	robots     map[string]*hostRobots // by scheme and host
+
+	unfollowed []*Page // pages only linked from elements that aren't followed
+
+	errsLock sync.Mutex
+	errs     Errors
+
+	onPageLock sync.Mutex // calls to onPage one at a time
+}
+
+// fail records that page failed with err. It returns the error that should
+// stop the crawl, which is only the case when failing fast
+func (r *run) fail(page *Page, u *url.URL, err error) error {
+	r.logf("!!!failed to crawl %s: %v\n", u.String(), err)
+	page.Err = err
+	pe := &PageError{URL: u.String(), Err: err}
+	if r.failFast {
+		return pe
+	}
+	r.errsLock.Lock()
+	r.errs = append(r.errs, pe)
+	r.errsLock.Unlock()
+	return nil
+}
+
+// Crawl crawls the page from the url link and it's sublinks using a Crawler
+// with default settings
+func Crawl(urlstring string) (*Graph, error) {
+	return New().Crawl(urlstring)
+}
+
+// CrawlContext is like Crawl but stops when ctx is done
+func CrawlContext(ctx context.Context, urlstring string) (*Graph, error) {
+	return New().CrawlContext(ctx, urlstring)
 }
 
 // Crawl crawls the page from the url link and it's sublinks
-func Crawl(urlstring string) (*Page, error) {
+func (c *Crawler) Crawl(urlstring string) (*Graph, error) {
+	return c.CrawlContext(context.Background(), urlstring)
+}
 
-	// use a hash map to keep unique links
-	var allLinks = make(map[string]*Page)
-	var allLinksLock sync.Mutex // protect all links read & write
-	var siteRoot *url.URL
-	var siteTitle = urlstring
+// CrawlContext crawls the page from the url link and it's sublinks, breadth
+// first. Every request is made with ctx. When ctx is done, the pages
+// collected so far are returned together with ctx.Err(). Pages that fail keep
+// their error in Page.Err, and the crawl returns all pages together with an
+// Errors listing them, unless failing fast. The same site gives the same
+// graph, with pages and links in the same order, however long each page
+// takes to fetch
+func (c *Crawler) CrawlContext(ctx context.Context, urlstring string) (*Graph, error) {
+	seed, err := url.Parse(urlstring)
+	if err != nil {
+		return nil, err
+	}
+	if seed.Hostname() == "" {
+		return nil, errors.New("unable to recognise site root url")
+	}
+	if seed.Scheme != "http" && seed.Scheme != "https" {
+		return nil, errors.Errorf("unsupported scheme %s at url %s", seed.Scheme, urlstring)
+	}
+	// the seed is fetched as given, only its key in the graph is normalised
+	seed.Fragment = ""
+	if seed.Path == "" {
+		seed.Path = "/"
+	}
+
+	g := newGraph()
+	r := &run{
+		Crawler:   c,
+		taskQueue: make(chan struct{}, c.concurrency),
+		robots:    make(map[string]*hostRobots),
+		hosts:     newHostLimits(c.rps, c.delay, c.hostConcurrency),
+		limits:    newLimits(c),
+	}
 
-	var crawl func(ctx context.Context, urlstring, description string) (*Page, error)
-	crawl = func(ctx context.Context, urlstring, description string) (*Page, error) {
-		u, err := url.Parse(urlstring)
-		if err != nil {
-			return nil, err
+	g.Root, _ = g.add(c.normalizer.Normalize(seed))
+	f := &frontier{}
+	admitted, err := r.admit(ctx, g.Root, seed)
+	if err != nil {
+		return nil, err
+	}
+	if admitted {
+		f.push(g.Root, seed)
+	} else {
+		r.done(g, g.Root)
+	}
+	err = r.crawlLevels(ctx, g, seed, f)
+	// pages no followed link led to are done once that's certain
+	for _, p := range r.unfollowed {
+		if p.NotCrawled == notFollowed {
+			r.done(g, p)
 		}
-		// normalise root
-		if u.Path == "" {
-			u.Path = "/"
+	}
+	if ctx.Err() != nil {
+		for _, p := range g.Pages {
+			if p.StatusCode == 0 && p.NotCrawled == "" && p.Err == nil {
+				p.NotCrawled = "crawl stopped"
+			}
 		}
+		return g, ctx.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(r.errs) > 0 {
+		r.errs.sort()
+		return g, r.errs
+	}
+	return g, nil
+}
 
-		if u.Hostname() != "" {
-			root, err := url.Parse("/")
-			if err != nil {
-				panic(err)
+// crawlLevels crawls the pages in the frontier a level at a time, until no
+// new pages are found. Up to the concurrency limit pages of a level are
+// fetched at once, but their links are added to g in the order of the level,
+// so that g doesn't depend on how fast each page was fetched: each page as
+// soon as it and all pages before it in the level are fetched. It stops at
+// the first error visit returns
+func (r *run) crawlLevels(ctx context.Context, g *Graph, seed *url.URL, f *frontier) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for level := f.level(); len(level) > 0; level = f.level() {
+		var mu sync.Mutex
+		docs := make([]*document, len(level))
+		finished := make([]bool, len(level))
+		visited := make([]bool, len(level))
+		next := 0 // the first page in the level whose links aren't added yet
+		finish := func(i int, doc *document, ok bool) {
+			mu.Lock()
+			defer mu.Unlock()
+			docs[i], finished[i], visited[i] = doc, true, ok
+			for ; next < len(level) && finished[next]; next++ {
+				// pages given up on when the crawl stopped aren't done
+				if visited[next] {
+					r.addLinks(ctx, g, seed, f, level[next].page, docs[next])
+				}
+				docs[next] = nil
 			}
-			siteRoot = u.ResolveReference(root)
-		} else if siteRoot != nil {
-			u = siteRoot.ResolveReference(u)
-		} else {
-			return nil, errors.New("unable to recognise site root url")
 		}
 
-		// the key value to test uniqueness
-		key := sanitise(u.Path)
-
-		if u.Scheme != "http" && u.Scheme != "https" {
-			debugf("!!!unsupported scheme %s at url %s\n", u.Scheme, u.String())
-			return nil, nil
+		queue := make(chan int)
+		errs := make(chan error, r.concurrency)
+		workers := r.concurrency
+		if workers > len(level) {
+			workers = len(level)
 		}
-
-		allLinksLock.Lock()
-		existing := allLinks[key]
-
-		if existing != nil {
-			allLinksLock.Unlock()
-			return existing, nil
+		for i := 0; i < workers; i++ {
+			go func() {
+				var firstErr error
+				for i := range queue {
+					// once ctx is done the remaining pages return promptly
+					// because every request is made with ctx
+					page := level[i].page
+					doc, err := r.visit(ctx, page, level[i].url)
+					if err != nil && firstErr == nil {
+						firstErr = err
+						cancel()
+					}
+					r.prefetchRobots(ctx, seed, doc)
+					finish(i, doc, err == nil || page.Err != nil)
+				}
+				errs <- firstErr
+			}()
 		}
-		page := &Page{
-			Info: URL{URI: key, Description: description},
+		for i := range level {
+			queue <- i
 		}
-		allLinks[key] = page
-		allLinksLock.Unlock()
+		close(queue)
 
-		debugf("crawling %s ...\n", u.String())
-		resp, err := doGet(u.String())
-		if err != nil {
-			return nil, err
+		var firstErr error
+		for i := 0; i < workers; i++ {
+			if err := <-errs; err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		if firstErr != nil {
+			return firstErr
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
 		}
+	}
+	return nil
+}
 
-		if resp.StatusCode != 200 {
-			debugf("!!!server returned %d for %s\n", resp.StatusCode, u.String())
-			page.Info.Description = fmt.Sprintf("%s (%d)", description, resp.StatusCode)
-			return page, nil
+// addLinks adds the followed links in doc, found on page, to g in the order
+// they appear on the page. Pages found for the first time are queued in f
+// for the next level, if they may be fetched. Then page is done
+func (r *run) addLinks(ctx context.Context, g *Graph, seed *url.URL, f *frontier, page *Page, doc *document) {
+	var skipped []*Page
+	if doc != nil {
+		for _, l := range doc.Links {
+			lu, k, ok := r.resolve(seed, l)
+			if !ok {
+				continue
+			}
+			to, created := g.add(k)
+			g.link(page, to, l)
+			follow := r.follow[l.Element]
+			if created && !follow {
+				to.Depth = page.Depth + 1
+				to.NotCrawled = notFollowed
+				r.unfollowed = append(r.unfollowed, to)
+				continue
+			}
+			// a page only linked from elements that aren't followed is
+			// fetched once a followed link leads to it
+			if !follow || !created && to.NotCrawled != notFollowed {
+				continue
+			}
+			to.Depth = page.Depth + 1
+			to.NotCrawled = ""
+			// if ctx is done, the page is left for CrawlContext to mark
+			admitted, err := r.admit(ctx, to, lu)
+			if err != nil {
+				continue
+			}
+			if admitted {
+				f.push(to, lu)
+			} else {
+				skipped = append(skipped, to)
+			}
 		}
+	}
+	r.done(g, page)
+	// pages that won't be fetched are done as soon as they're found
+	for _, p := range skipped {
+		r.done(g, p)
+	}
+}
 
-		urls, err := parse(u, resp.Body)
-		if err != nil {
-			return nil, err
+// resolve returns the url of link l, without its fragment, and the
+// normalised url identifying its page. ok is false if the link is invalid or
+// out of scope
+func (r *run) resolve(seed *url.URL, l URL) (u *url.URL, k string, ok bool) {
+	u, err := url.Parse(l.URI)
+	if err != nil {
+		return nil, "", false
+	}
+	u.Fragment = ""
+	// the normalised url only identifies the page: scope patterns match it,
+	// but the page is fetched at the first url found for it
+	k = r.normalizer.Normalize(u)
+	ku, err := url.Parse(k)
+	if err != nil || !r.scope(seed, ku) {
+		return nil, "", false
+	}
+	return u, k, true
+}
+
+// prefetchRobots gets the robots.txt of each host the followed links in doc
+// lead to, so admitting them doesn't wait on a fetch while the level is locked
+func (r *run) prefetchRobots(ctx context.Context, seed *url.URL, doc *document) {
+	if doc == nil || r.ignoreRobots {
+		return
+	}
+	seen := make(map[string]bool)
+	for _, l := range doc.Links {
+		if !r.follow[l.Element] {
+			continue
+		}
+		u, _, ok := r.resolve(seed, l)
+		if !ok || seen[u.Scheme+"://"+u.Host] {
+			continue
+		}
+		seen[u.Scheme+"://"+u.Host] = true
+		if _, err := r.robotsFor(ctx, u); err != nil {
+			return
 		}
-		resp.Body.Close()
+	}
+}
 
-		wg := &sync.WaitGroup{}
-		pc := make(chan *Page)
-		errs := make(chan error)
+// admit reports whether the page at u may be fetched, judged by the
+// robots.txt of its host and the limits. If not, the page's NotCrawled says
+// why. It returns an error only if ctx is done
+func (r *run) admit(ctx context.Context, page *Page, u *url.URL) (bool, error) {
+	rb, err := r.robotsFor(ctx, u)
+	if err != nil {
+		return false, err
+	}
+	if !rb.allowed(u) {
+		r.logf("!!!%s is disallowed by robots.txt\n", u.String())
+		page.NotCrawled = "disallowed by robots.txt"
+		return false, nil
+	}
+	if reason := r.limits.take(page, u); reason != "" {
+		r.logf("!!!not crawling %s: %s\n", u.String(), reason)
+		page.NotCrawled = reason
+		return false, nil
+	}
+	return true, nil
+}
 
-		ctx, cancel := context.WithCancel(ctx)
-		defer cancel()
-		for _, u := range urls {
-			wg.Add(1)
-			go func(u URL) {
-				defer wg.Done()
-				l, err := crawl(ctx, u.URI, u.Description)
-				if err != nil {
-					errs <- err
-				} else {
-					pc <- l
-				}
-			}(u)
-		}
-		go func() {
-			wg.Wait()
-			close(errs)
-		}()
-
-		var links []*Page
-	loop:
-		for {
-			select {
-			case page := <-pc:
-				if page != nil {
-					links = append(links, page)
-				}
-			case err := <-errs:
-				if err != nil {
-					cancel()
-					return nil, err
-				}
-				break loop
-			case <-ctx.Done():
-				if ctx.Err() == context.Canceled {
-					break loop
-				} else {
-					return nil, ctx.Err()
-				}
-			}
+// visit fetches and parses the page at u. It returns nil if the page has no
+// links to follow, and an error only if the crawl should stop
+func (r *run) visit(ctx context.Context, page *Page, u *url.URL) (*document, error) {
+	r.logf("crawling %s ...\n", u.String())
+	resp, t, err := r.get(ctx, u.String())
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
 		}
+		return nil, r.fail(page, u, err)
+	}
+	defer resp.Body.Close()
+	page.setResponse(resp, t)
 
-		page.Links = links
+	if resp.StatusCode != 200 {
+		r.logf("!!!server returned %d for %s\n", resp.StatusCode, u.String())
+		return nil, nil
+	}
+	if !isHTML(page.ContentType) {
+		return nil, nil
+	}
 
-		return page, nil
+	// relative links are relative to where any redirects ended up
+	docURL := u
+	if final, err := url.Parse(resp.URL); err == nil && resp.URL != "" {
+		docURL = final
 	}
+	body := &countingReader{r: resp.Body}
+	doc, err := parse(docURL, body)
+	if page.ContentLength < 0 {
+		page.ContentLength = body.n
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, r.fail(page, u, err)
+	}
+	page.Title = doc.Title
+	page.Diagnostics = doc.Diagnostics
+	for _, d := range doc.Diagnostics {
+		r.logf("!!!skipping malformed link %q on %s: %s\n", d.Raw, d.Source, d.Reason)
+	}
+	return doc, nil
+}
 
-	ctx := context.Background()
+// isHTML reports whether a page of the media type may have links. Pages
+// without a content type are assumed to be html
+func isHTML(mediaType string) bool {
+	return mediaType == "" || mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
 
-	return crawl(ctx, urlstring, siteTitle)
+// done passes a page, once it's been visited and its links added to g, to
+// the OnPage handler
+func (r *run) done(g *Graph, page *Page) {
+	if r.onPage == nil {
+		return
+	}
+	r.onPageLock.Lock()
+	defer r.onPageLock.Unlock()
+	r.onPage(page, g.Outbound(page))
 }
 
-func doGet(u string) (*http.Response, error) {
-	globalTaskQueue <- struct{}{}
-	defer func() { <-globalTaskQueue }()
-	return http.Get(u)
+// timing is when a request started and how long it took to get the response
+type timing struct {
+	start   time.Time
+	elapsed time.Duration
 }
 
-func debugf(format string, args ...interface{}) {
-	if Verbose {
-		fmt.Printf(format, args...)
+// fetch fetches u with ctx once the host limits allow it, making at most
+// cap(r.taskQueue) requests at a time
+func (r *run) fetch(ctx context.Context, u string) (*Response, timing, error) {
+	pu, err := url.Parse(u)
+	if err != nil {
+		return nil, timing{}, err
+	}
+	release, err := r.hosts.acquire(ctx, pu.Host)
+	if err != nil {
+		return nil, timing{}, err
 	}
+	defer release()
+
+	select {
+	case r.taskQueue <- struct{}{}:
+	case <-ctx.Done():
+		return nil, timing{}, ctx.Err()
+	}
+	defer func() { <-r.taskQueue }()
+
+	start := time.Now()
+	resp, err := r.fetcher.Fetch(ctx, u)
+	return resp, timing{start: start, elapsed: time.Since(start)}, err
 }
 
-func sanitise(s string) string {
-	s = strings.Replace(s, "\n", "", -1)
-	s = strings.Replace(s, " ", "", -1)
-	return s
+// setResponse records the metadata of resp on the page
+func (p *Page) setResponse(resp *Response, t timing) {
+	p.StatusCode = resp.StatusCode
+	p.FinalURL = resp.URL
+	p.ContentType, _, _ = mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	p.ContentLength = -1
+	if n, err := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64); err == nil {
+		p.ContentLength = n
+	}
+	p.ResponseTime = t.elapsed
+	p.FetchedAt = t.start
+	if lm, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		p.LastModified = lm
+	}
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *Crawler) logf(format string, args ...interface{}) {
+	if c.logger != nil {
+		c.logger.Printf(format, args...)
+	}
 }
@@ -1,11 +1,14 @@
 package crawler
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"log"
 	"math/rand"
 	"net/http"
 	"net/http/httptest"
-	"strings"
+	"os"
 	"testing"
 	"time"
 )
@@ -41,34 +44,7 @@ const htmlCareer = `
 </html>
 `
 
-func TestParse(t *testing.T) {
-	links, err := parse(strings.NewReader(htmlHome))
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	expects := []URL{
-		{URI: "/", Description: "home"},
-		{URI: "/about", Description: "about"},
-		{URI: "/products", Description: "products"},
-	}
-
-	if len(links) != len(expects) {
-		t.Fatalf("expect sublinks to have length of %d, got %d", len(expects), len(links))
-	}
-
-	for i := range expects {
-		if expects[i].URI != links[i].URI {
-			t.Errorf("expecting link uri to be %s, got %s", expects[i].URI, links[i].URI)
-		}
-		if expects[i].Description != links[i].Description {
-			t.Errorf("expecting link description to be %s, got %s", expects[i].Description, links[i].Description)
-		}
-	}
-}
-
 func newTestServer() *httptest.Server {
-	Verbose = true
 	rand.Seed(1500)
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -100,49 +76,117 @@ func newTestServer() *httptest.Server {
 
 func TestCrawl(t *testing.T) {
 	server := newTestServer()
-	page, err := Crawl(server.URL)
+	c := New(WithLogger(log.New(os.Stdout, "", 0)))
+	g, err := c.Crawl(server.URL)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if page == nil {
-		t.Fatal("expect page to be not nil")
+	if g == nil {
+		t.Fatal("expect graph to be not nil")
 	}
-	if page.Info.URI != "/" {
-		t.Errorf("expect page url to be %s, got %s", "/", page.Info.URI)
+	page := g.Root
+	if page.URL != server.URL+"/" {
+		t.Errorf("expect page url to be %s, got %s", server.URL+"/", page.URL)
 	}
-	if len(page.Links) != 3 {
-		t.Fatalf("expect 3 links got %d", len(page.Links))
+	if len(g.Pages) != 4 {
+		t.Errorf("expect 4 pages got %d", len(g.Pages))
 	}
-
-	var home *Page
-	var about *Page
-	var products *Page
-	for _, link := range page.Links {
-		switch link.Info.URI {
-		case "/":
-			home = link
-		case "/about":
-			about = link
-		case "/products":
-			products = link
-		}
+	links := g.Outbound(page)
+	if len(links) != 3 {
+		t.Fatalf("expect 3 links got %d", len(links))
 	}
-	if home == nil {
-		t.Fatalf("expect home page url to be /, got %s", home.Info.URI)
+	if links[0].To != page || links[0].Text != "home" {
+		t.Errorf("expect first link to be home, got %s %q", links[0].To.URL, links[0].Text)
 	}
+
+	about := g.Page(server.URL + "/about")
 	if about == nil {
-		t.Fatalf("expect about page url to be /about, got %s", about.Info.URI)
+		t.Fatal("expect about page to be crawled")
 	}
-	if len(about.Links) != 2 {
-		t.Fatalf("expect about page to have 1 link got %d", len(about.Links))
+	if links := g.Outbound(about); len(links) != 2 {
+		t.Fatalf("expect about page to have 2 links got %d", len(links))
+	} else if links[1].To.URL != server.URL+"/career" {
+		t.Errorf("expect career page url to be %s/career, got %s", server.URL, links[1].To.URL)
 	}
-	career := about.Links[1]
-	if career.Info.URI != "/career" {
-		t.Errorf("expect career page url to be /career, got %s", career.Info.URI)
+	if in := g.Inbound(page); len(in) != 2 {
+		t.Errorf("expect home page to have 2 inbound links got %d", len(in))
 	}
 
+	products := g.Page(server.URL + "/products")
 	if products == nil {
-		t.Fatalf("expect products page url to be /products, got %s", products.Info.URI)
+		t.Fatal("expect products page to be crawled")
+	}
+	if products.StatusCode != http.StatusNotFound {
+		t.Errorf("expect products page to be not found, got %d", products.StatusCode)
+	}
+}
+
+func TestCrawlContext(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(htmlAbout))
+	})
+	mux.HandleFunc("/career", func(w http.ResponseWriter, r *http.Request) {
+		// never answers before the client gives up
+		<-r.Context().Done()
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	g, err := New().CrawlContext(ctx, server.URL)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expect error to be %v, got %v", context.DeadlineExceeded, err)
+	}
+	if g == nil {
+		t.Fatal("expect partial graph to be not nil")
+	}
+	if g.Root.URL != server.URL+"/" {
+		t.Errorf("expect page url to be %s, got %s", server.URL+"/", g.Root.URL)
 	}
+	if links := g.Outbound(g.Root); len(links) != 2 {
+		t.Errorf("expect 2 links got %d", len(links))
+	}
+}
 
+func TestCrawlDeterministic(t *testing.T) {
+	f := fixtures{
+		"http://example.com/":    `<a href="/a">a</a><a href="/b">b</a><a href="/c">c</a>`,
+		"http://example.com/a":   `<a href="/c">c</a><a href="/a/1">1</a><a href="/">home</a>`,
+		"http://example.com/b":   `<a href="/a/1">1</a><a href="/b/1">1</a>`,
+		"http://example.com/c":   `<a href="/b/1">1</a><a href="/a">a</a>`,
+		"http://example.com/a/1": htmlCareer,
+		"http://example.com/b/1": htmlCareer,
+	}
+	fetcher := FetcherFunc(func(ctx context.Context, url string) (*Response, error) {
+		time.Sleep(time.Duration(rand.Int63n(int64(20 * time.Millisecond))))
+		return f.Fetch(ctx, url)
+	})
+	var expect string
+	for i := 0; i < 5; i++ {
+		var streamed []string
+		g, err := New(WithFetcher(fetcher), WithOnPage(func(p *Page, links []*Link) {
+			streamed = append(streamed, p.URL)
+		})).Crawl("http://example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b bytes.Buffer
+		fmt.Fprintln(&b, streamed)
+		for _, p := range g.Pages {
+			fmt.Fprintln(&b, p.URL, p.Depth)
+			for _, l := range g.Inbound(p) {
+				fmt.Fprintln(&b, " <-", l.From.URL, l.Text)
+			}
+		}
+		for _, l := range g.Links {
+			fmt.Fprintln(&b, l.From.URL, "->", l.To.URL, l.Text)
+		}
+		if i == 0 {
+			expect = b.String()
+		} else if b.String() != expect {
+			t.Fatalf("expect every crawl to give\n%s\ngot\n%s", expect, b.String())
+		}
+	}
 }
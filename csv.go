@@ -0,0 +1,31 @@
+package crawler
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// WritePagesCSV writes one row per page with the columns url, status,
+// content_type, depth and title
+func WritePagesCSV(w io.Writer, g *Graph) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"url", "status", "content_type", "depth", "title"})
+	for _, p := range g.Pages {
+		cw.Write([]string{p.URL, strconv.Itoa(p.StatusCode), p.ContentType, strconv.Itoa(p.Depth), p.Title})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteLinksCSV writes one row per link with the columns source, target,
+// text, rel and element
+func WriteLinksCSV(w io.Writer, g *Graph) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"source", "target", "text", "rel", "element"})
+	for _, l := range g.Links {
+		cw.Write([]string{l.From.URL, l.To.URL, l.Text, l.Rel, l.Element})
+	}
+	cw.Flush()
+	return cw.Error()
+}
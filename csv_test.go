@@ -0,0 +1,43 @@
+package crawler
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteCSV(t *testing.T) {
+	g := testDiagramGraph()
+	g.Root.Title = `Home, "sweet" home`
+	g.Root.ContentType = "text/html"
+
+	var buf bytes.Buffer
+	if err := WritePagesCSV(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	expect := `url,status,content_type,depth,title
+http://example.com/,200,text/html,0,"Home, ""sweet"" home"
+http://example.com/blog,200,,0,
+http://example.com/blog/post,404,,0,
+http://example.com/blog/other,500,,0,
+`
+	if buf.String() != expect {
+		t.Errorf("expect pages\n%s\ngot\n%s", expect, buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteLinksCSV(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	expect = `source,target,text,rel,element
+http://example.com/,http://example.com/blog,Blog,,a
+http://example.com/blog,http://example.com/,Home,,a
+http://example.com/blog,http://example.com/blog/post,Post,,a
+http://example.com/blog,http://example.com/blog/post,Read more,,a
+http://example.com/blog,http://example.com/blog/other,Other,,a
+http://example.com/blog/post,http://example.com/,Home,,a
+http://example.com/blog/other,http://example.com/,Home,,a
+`
+	if buf.String() != expect {
+		t.Errorf("expect links\n%s\ngot\n%s", expect, buf.String())
+	}
+}
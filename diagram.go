@@ -0,0 +1,267 @@
+package crawler
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// DiagramOptions configures WriteDOT and WriteMermaid
+type DiagramOptions struct {
+	// ClusterDepth groups pages sharing their first ClusterDepth path
+	// segments, e.g. 1 puts /blog/a and /blog/b together. 0 turns it off
+	ClusterDepth int
+
+	// Collapse lists pages, by url or path, whose inbound links are left
+	// out. Use it for navigation links such as / and /blog that are on
+	// every page
+	Collapse []string
+
+	// CollapseFanIn collapses every page linked from at least this many
+	// pages. 0 turns it off
+	CollapseFanIn int
+}
+
+// diagram is a graph prepared for drawing: links between the same two
+// pages are merged, and links to collapsed pages left out
+type diagram struct {
+	nodes    []*diagramNode
+	clusters []*diagramCluster // in the order of their first page
+	edges    [][2]*diagramNode
+}
+
+type diagramNode struct {
+	id     string
+	label  string
+	class  string // one of diagramColors
+	hidden int    // inbound links left out when collapsed
+}
+
+type diagramCluster struct {
+	name  string
+	nodes []*diagramNode
+}
+
+// diagramColors colours pages by status
+var diagramColors = []struct{ class, color string }{
+	{"ok", "#c8e6c9"},
+	{"redirect", "#bbdefb"},
+	{"client_error", "#ffe0b2"},
+	{"server_error", "#ffcdd2"},
+	{"not_crawled", "#eeeeee"},
+}
+
+func diagramClass(p *Page) string {
+	switch {
+	case p.Err != nil || p.StatusCode >= 500:
+		return "server_error"
+	case p.StatusCode >= 400:
+		return "client_error"
+	case p.StatusCode >= 300:
+		return "redirect"
+	case p.StatusCode >= 200:
+		return "ok"
+	}
+	return "not_crawled"
+}
+
+func newDiagram(g *Graph, opts DiagramOptions) *diagram {
+	var root *url.URL
+	if g.Root != nil {
+		root, _ = url.Parse(g.Root.URL)
+	}
+	collapse := make(map[string]bool)
+	for _, c := range opts.Collapse {
+		collapse[trimSlash(c)] = true
+	}
+
+	d := &diagram{}
+	nodes := make(map[*Page]*diagramNode)
+	collapsed := make(map[*Page]bool)
+	clusters := make(map[string]*diagramCluster)
+	for i, p := range g.Pages {
+		n := &diagramNode{id: fmt.Sprintf("n%d", i), label: p.URL, class: diagramClass(p)}
+		u, err := url.Parse(p.URL)
+		if err == nil && root != nil && u.Host == root.Host {
+			n.label = u.RequestURI()
+		}
+		nodes[p] = n
+		d.nodes = append(d.nodes, n)
+
+		if collapse[trimSlash(p.URL)] || collapse[trimSlash(n.label)] {
+			collapsed[p] = true
+		}
+		if opts.CollapseFanIn > 0 && fanIn(g, p) >= opts.CollapseFanIn {
+			collapsed[p] = true
+		}
+
+		if opts.ClusterDepth > 0 && err == nil {
+			name := clusterName(u.Path, opts.ClusterDepth)
+			if name == "" {
+				continue
+			}
+			c := clusters[name]
+			if c == nil {
+				c = &diagramCluster{name: name}
+				clusters[name] = c
+				d.clusters = append(d.clusters, c)
+			}
+			c.nodes = append(c.nodes, n)
+		}
+	}
+
+	// a cluster of one page is just noise
+	var kept []*diagramCluster
+	for _, c := range d.clusters {
+		if len(c.nodes) > 1 {
+			kept = append(kept, c)
+		}
+	}
+	d.clusters = kept
+
+	seen := make(map[[2]*diagramNode]bool)
+	for _, l := range g.Links {
+		from, to := nodes[l.From], nodes[l.To]
+		e := [2]*diagramNode{from, to}
+		if seen[e] {
+			continue
+		}
+		seen[e] = true
+		if collapsed[l.To] {
+			to.hidden++
+			continue
+		}
+		d.edges = append(d.edges, e)
+	}
+	for _, n := range d.nodes {
+		if n.hidden > 0 {
+			n.label = fmt.Sprintf("%s (%d inbound hidden)", n.label, n.hidden)
+		}
+	}
+	return d
+}
+
+// fanIn returns the number of distinct pages linking to p
+func fanIn(g *Graph, p *Page) int {
+	from := make(map[*Page]bool)
+	for _, l := range g.Inbound(p) {
+		from[l.From] = true
+	}
+	return len(from)
+}
+
+// clusterName returns the first depth segments of path, or "" if the path
+// is too short to be in a cluster
+func clusterName(path string, depth int) string {
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	if segs[0] == "" {
+		return ""
+	}
+	if len(segs) < depth {
+		depth = len(segs)
+	}
+	return "/" + strings.Join(segs[:depth], "/")
+}
+
+func trimSlash(s string) string {
+	if len(s) > 1 {
+		return strings.TrimSuffix(s, "/")
+	}
+	return s
+}
+
+// WriteDOT writes the graph in Graphviz DOT format
+func WriteDOT(w io.Writer, g *Graph, opts DiagramOptions) error {
+	d := newDiagram(g, opts)
+	ew := &errWriter{w: w}
+	ew.printf("digraph site {\n")
+	ew.printf("  rankdir=LR;\n")
+	ew.printf("  node [shape=box, style=filled, fontname=\"Helvetica\"];\n")
+
+	inCluster := make(map[*diagramNode]bool)
+	for i, c := range d.clusters {
+		ew.printf("  subgraph cluster_%d {\n", i)
+		ew.printf("    label=%s;\n", dotQuote(c.name))
+		for _, n := range c.nodes {
+			ew.printf("    %s;\n", dotNode(n))
+			inCluster[n] = true
+		}
+		ew.printf("  }\n")
+	}
+	for _, n := range d.nodes {
+		if !inCluster[n] {
+			ew.printf("  %s;\n", dotNode(n))
+		}
+	}
+	for _, e := range d.edges {
+		ew.printf("  %s -> %s;\n", e[0].id, e[1].id)
+	}
+	ew.printf("}\n")
+	return ew.err
+}
+
+func dotNode(n *diagramNode) string {
+	color := ""
+	for _, c := range diagramColors {
+		if c.class == n.class {
+			color = c.color
+		}
+	}
+	return fmt.Sprintf("%s [label=%s, fillcolor=%s]", n.id, dotQuote(n.label), dotQuote(color))
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.Replace(strings.Replace(s, `\`, `\\`, -1), `"`, `\"`, -1) + `"`
+}
+
+// WriteMermaid writes the graph as a Mermaid flowchart
+func WriteMermaid(w io.Writer, g *Graph, opts DiagramOptions) error {
+	d := newDiagram(g, opts)
+	ew := &errWriter{w: w}
+	ew.printf("flowchart LR\n")
+	for _, c := range diagramColors {
+		ew.printf("  classDef %s fill:%s,stroke:#555\n", c.class, c.color)
+	}
+
+	inCluster := make(map[*diagramNode]bool)
+	for i, c := range d.clusters {
+		ew.printf("  subgraph cluster_%d [%s]\n", i, mermaidQuote(c.name))
+		for _, n := range c.nodes {
+			ew.printf("    %s\n", mermaidNode(n))
+			inCluster[n] = true
+		}
+		ew.printf("  end\n")
+	}
+	for _, n := range d.nodes {
+		if !inCluster[n] {
+			ew.printf("  %s\n", mermaidNode(n))
+		}
+	}
+	for _, e := range d.edges {
+		ew.printf("  %s --> %s\n", e[0].id, e[1].id)
+	}
+	return ew.err
+}
+
+func mermaidNode(n *diagramNode) string {
+	return fmt.Sprintf("%s[%s]:::%s", n.id, mermaidQuote(n.label), n.class)
+}
+
+func mermaidQuote(s string) string {
+	return `"` + strings.Replace(s, `"`, "#quot;", -1) + `"`
+}
+
+// errWriter keeps the first write error so that formatting code can write
+// without checking every call
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...interface{}) {
+	if ew.err != nil {
+		return
+	}
+	_, ew.err = fmt.Fprintf(ew.w, format, args...)
+}
@@ -0,0 +1,74 @@
+package crawler
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func testDiagramGraph() *Graph {
+	g := newGraph()
+	home, _ := g.add("http://example.com/")
+	home.StatusCode = 200
+	blog, _ := g.add("http://example.com/blog")
+	blog.StatusCode = 200
+	post, _ := g.add("http://example.com/blog/post")
+	post.StatusCode = 404
+	other, _ := g.add("http://example.com/blog/other")
+	other.StatusCode = 500
+	g.Root = home
+	g.link(home, blog, URL{Description: "Blog", Element: "a"})
+	g.link(blog, home, URL{Description: "Home", Element: "a"})
+	g.link(blog, post, URL{Description: "Post", Element: "a"})
+	g.link(blog, post, URL{Description: "Read more", Element: "a"})
+	g.link(blog, other, URL{Description: "Other", Element: "a"})
+	g.link(post, home, URL{Description: "Home", Element: "a"})
+	g.link(other, home, URL{Description: "Home", Element: "a"})
+	return g
+}
+
+func TestWriteDOT(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteDOT(&buf, testDiagramGraph(), DiagramOptions{ClusterDepth: 1, Collapse: []string{"/"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, expect := range []string{
+		`subgraph cluster_0 {`,
+		`label="/blog";`,
+		`n0 [label="/ (3 inbound hidden)", fillcolor="#c8e6c9"];`,
+		`n2 [label="/blog/post", fillcolor="#ffe0b2"];`,
+		`n1 -> n2;`,
+	} {
+		if !strings.Contains(out, expect) {
+			t.Errorf("expect dot to contain %s, got\n%s", expect, out)
+		}
+	}
+	if strings.Contains(out, "-> n0") {
+		t.Errorf("expect links to / to be collapsed, got\n%s", out)
+	}
+	if strings.Count(out, "n1 -> n2;") != 1 {
+		t.Errorf("expect links between the same pages to be merged, got\n%s", out)
+	}
+}
+
+func TestWriteMermaid(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMermaid(&buf, testDiagramGraph(), DiagramOptions{CollapseFanIn: 3}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, expect := range []string{
+		"flowchart LR\n",
+		`n3["/blog/other"]:::server_error`,
+		"n1 --> n3\n",
+	} {
+		if !strings.Contains(out, expect) {
+			t.Errorf("expect mermaid to contain %s, got\n%s", expect, out)
+		}
+	}
+	if strings.Contains(out, "--> n0") {
+		t.Errorf("expect links to / to be collapsed, got\n%s", out)
+	}
+}
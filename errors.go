@@ -0,0 +1,36 @@
+package crawler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PageError is an error that happened while crawling one page
+type PageError struct {
+	URL string
+	Err error
+}
+
+func (e *PageError) Error() string {
+	return fmt.Sprintf("%s: %v", e.URL, e.Err)
+}
+
+// Errors is returned, together with the crawled pages, when some pages
+// failed to crawl. It's sorted by url
+type Errors []*PageError
+
+func (e Errors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	msgs := make([]string, len(e))
+	for i, pe := range e {
+		msgs[i] = pe.Error()
+	}
+	return fmt.Sprintf("%d pages failed to crawl:\n\t%s", len(e), strings.Join(msgs, "\n\t"))
+}
+
+func (e Errors) sort() {
+	sort.Slice(e, func(i, j int) bool { return e[i].URL < e[j].URL })
+}
@@ -0,0 +1,45 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCrawlPageErrors(t *testing.T) {
+	f := fixtures{
+		"http://example.com/":       htmlHome,
+		"http://example.com/career": htmlCareer,
+	}
+	broken := errors.New("no such host")
+	fetcher := FetcherFunc(func(ctx context.Context, url string) (*Response, error) {
+		if strings.HasSuffix(url, "/about") {
+			return nil, broken
+		}
+		return f.Fetch(ctx, url)
+	})
+
+	g, err := New(WithFetcher(fetcher), WithRetries(0)).Crawl("http://example.com")
+	errs, ok := err.(Errors)
+	if !ok {
+		t.Fatalf("expect error to be Errors, got %v", err)
+	}
+	if len(errs) != 1 || errs[0].URL != "http://example.com/about" || errs[0].Err != broken {
+		t.Errorf("expect one error for /about, got %v", errs)
+	}
+	if g == nil || len(g.Pages) != 3 {
+		t.Fatal("expect the crawl to carry on with 3 pages")
+	}
+	if about := g.Page("http://example.com/about"); about.Err != broken {
+		t.Errorf("expect about page error to be %v, got %v", broken, about.Err)
+	}
+
+	g, err = New(WithFetcher(fetcher), WithRetries(0), WithFailFast(true)).Crawl("http://example.com")
+	if pe, ok := err.(*PageError); !ok || pe.Err != broken {
+		t.Errorf("expect fail fast error to be %v, got %v", broken, err)
+	}
+	if g != nil {
+		t.Error("expect no graph when failing fast")
+	}
+}
@@ -0,0 +1,60 @@
+package crawler
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// Response is the result of fetching a url
+type Response struct {
+	URL        string // final url after any redirects
+	StatusCode int
+	Header     http.Header
+	Body       io.ReadCloser // must be closed by the caller
+}
+
+// Fetcher fetches a url for the crawler. Implementations may add auth,
+// proxies, caching or serve canned fixtures
+type Fetcher interface {
+	Fetch(ctx context.Context, url string) (*Response, error)
+}
+
+// FetcherFunc adapts an ordinary function to a Fetcher
+type FetcherFunc func(ctx context.Context, url string) (*Response, error)
+
+// Fetch calls f(ctx, url)
+func (f FetcherFunc) Fetch(ctx context.Context, url string) (*Response, error) {
+	return f(ctx, url)
+}
+
+// HTTPFetcher is the default Fetcher. It makes GET requests with Client
+type HTTPFetcher struct {
+	Client    *http.Client // http.DefaultClient is used if nil
+	UserAgent string       // sent as the User-Agent header if not empty
+}
+
+// Fetch makes a GET request for url with ctx
+func (f *HTTPFetcher) Fetch(ctx context.Context, url string) (*Response, error) {
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if f.UserAgent != "" {
+		req.Header.Set("User-Agent", f.UserAgent)
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return &Response{
+		URL:        resp.Request.URL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       resp.Body,
+	}, nil
+}
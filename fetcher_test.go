@@ -0,0 +1,57 @@
+package crawler
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// fixtures is a Fetcher serving canned pages keyed by url
+type fixtures map[string]string
+
+func (f fixtures) Fetch(ctx context.Context, url string) (*Response, error) {
+	body, ok := f[url]
+	status := http.StatusOK
+	if !ok {
+		status = http.StatusNotFound
+	}
+	return &Response{
+		URL:        url,
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}, nil
+}
+
+func TestCrawlWithFetcher(t *testing.T) {
+	f := fixtures{
+		"http://example.com/":       htmlHome,
+		"http://example.com/about":  htmlAbout,
+		"http://example.com/career": htmlCareer,
+	}
+	g, err := New(WithFetcher(f)).Crawl("http://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := g.Root
+	if page.StatusCode != http.StatusOK || page.ContentType != "text/html" {
+		t.Errorf("expect home page to be 200 text/html, got %d %s", page.StatusCode, page.ContentType)
+	}
+	if page.FinalURL != "http://example.com/" {
+		t.Errorf("expect final url to be http://example.com/, got %s", page.FinalURL)
+	}
+	if page.ContentLength != int64(len(htmlHome)) {
+		t.Errorf("expect content length to be %d, got %d", len(htmlHome), page.ContentLength)
+	}
+	if page.FetchedAt.IsZero() {
+		t.Error("expect fetch time to be set")
+	}
+	if len(g.Outbound(page)) != 3 {
+		t.Fatalf("expect 3 links got %d", len(g.Outbound(page)))
+	}
+	if p := g.Page("http://example.com/products"); p.StatusCode != http.StatusNotFound {
+		t.Errorf("expect products to be not found, got %d", p.StatusCode)
+	}
+}
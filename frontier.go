@@ -0,0 +1,38 @@
+package crawler
+
+import (
+	"net/url"
+	"sync"
+)
+
+// frontier is the queue of pages waiting to be crawled. Pages are crawled a
+// level at a time, all pages n clicks from the seed before any page n+1
+// clicks away, so a page is always first found by a shortest path from the
+// seed and its Depth is its click depth
+type frontier struct {
+	mu   sync.Mutex
+	next []queued // pages found while crawling the current level
+}
+
+// queued is a page in the frontier and the url to fetch it from. The page's
+// Depth is the level it was found at
+type queued struct {
+	page *Page
+	url  *url.URL
+}
+
+// push queues page for the next level
+func (f *frontier) push(page *Page, u *url.URL) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.next = append(f.next, queued{page: page, url: u})
+}
+
+// level takes the pages queued for the next level
+func (f *frontier) level() []queued {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	level := f.next
+	f.next = nil
+	return level
+}
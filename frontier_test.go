@@ -0,0 +1,53 @@
+package crawler
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestCrawlBreadthFirst(t *testing.T) {
+	f := fixtures{
+		"http://example.com/":      `<a href="/a">a</a><a href="/b">b</a><a href="/faq">faq</a>`,
+		"http://example.com/a":     `<a href="/a/1">1</a>`,
+		"http://example.com/a/1":   `<a href="/a/1/2">2</a>`,
+		"http://example.com/a/1/2": `<a href="/faq">faq</a><a href="/deep">deep</a>`,
+		"http://example.com/b":     `<a href="/a/1/2">2</a>`,
+		"http://example.com/faq":   htmlCareer,
+		"http://example.com/deep":  htmlCareer,
+	}
+	var mu sync.Mutex
+	var fetched []string
+	fetcher := FetcherFunc(func(ctx context.Context, url string) (*Response, error) {
+		mu.Lock()
+		fetched = append(fetched, url)
+		mu.Unlock()
+		return f.Fetch(ctx, url)
+	})
+	g, err := New(WithFetcher(fetcher), WithIgnoreRobots(true)).Crawl("http://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	depths := map[string]int{
+		"http://example.com/":      0,
+		"http://example.com/a":     1,
+		"http://example.com/b":     1,
+		"http://example.com/faq":   1,
+		"http://example.com/a/1":   2,
+		"http://example.com/a/1/2": 2,
+		"http://example.com/deep":  3,
+	}
+	for u, depth := range depths {
+		if p := g.Page(u); p == nil || p.Depth != depth {
+			t.Errorf("expect %s at depth %d, got %+v", u, depth, p)
+		}
+	}
+	// every page is fetched after all pages closer to the root
+	for i := 1; i < len(fetched); i++ {
+		if depths[fetched[i]] < depths[fetched[i-1]] {
+			t.Errorf("expect pages fetched breadth first, got %v", fetched)
+			break
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package crawler
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type gexf struct {
+	XMLName xml.Name  `xml:"gexf"`
+	XMLNS   string    `xml:"xmlns,attr"`
+	Version string    `xml:"version,attr"`
+	Graph   gexfGraph `xml:"graph"`
+}
+
+type gexfGraph struct {
+	DefaultEdgeType string           `xml:"defaultedgetype,attr"`
+	Attributes      []gexfAttributes `xml:"attributes"`
+	Nodes           []gexfNode       `xml:"nodes>node"`
+	Edges           []gexfEdge       `xml:"edges>edge"`
+}
+
+type gexfAttributes struct {
+	Class      string          `xml:"class,attr"`
+	Attributes []gexfAttribute `xml:"attribute"`
+}
+
+type gexfAttribute struct {
+	ID    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfNode struct {
+	ID        string         `xml:"id,attr"`
+	Label     string         `xml:"label,attr"`
+	AttValues []gexfAttValue `xml:"attvalues>attvalue"`
+}
+
+type gexfEdge struct {
+	ID        string         `xml:"id,attr"`
+	Source    string         `xml:"source,attr"`
+	Target    string         `xml:"target,attr"`
+	Label     string         `xml:"label,attr,omitempty"`
+	AttValues []gexfAttValue `xml:"attvalues>attvalue"`
+}
+
+type gexfAttValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// WriteGEXF writes the graph as GEXF 1.2, e.g. for Gephi. Nodes are labelled
+// with their url and have status, depth, title and in_degree attributes;
+// edges are labelled with their text and have text and rel attributes
+func WriteGEXF(w io.Writer, g *Graph) error {
+	doc := gexf{
+		XMLNS:   "http://www.gexf.net/1.2draft",
+		Version: "1.2",
+		Graph: gexfGraph{
+			DefaultEdgeType: "directed",
+			Attributes: []gexfAttributes{
+				{Class: "node", Attributes: []gexfAttribute{
+					{ID: "status", Title: "status", Type: "integer"},
+					{ID: "depth", Title: "depth", Type: "integer"},
+					{ID: "title", Title: "title", Type: "string"},
+					{ID: "in_degree", Title: "in_degree", Type: "integer"},
+				}},
+				{Class: "edge", Attributes: []gexfAttribute{
+					{ID: "text", Title: "text", Type: "string"},
+					{ID: "rel", Title: "rel", Type: "string"},
+				}},
+			},
+		},
+	}
+
+	ids := nodeIDs(g)
+	for _, p := range g.Pages {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, gexfNode{
+			ID:    ids[p],
+			Label: p.URL,
+			AttValues: []gexfAttValue{
+				{For: "status", Value: strconv.Itoa(p.StatusCode)},
+				{For: "depth", Value: strconv.Itoa(p.Depth)},
+				{For: "title", Value: p.Title},
+				{For: "in_degree", Value: strconv.Itoa(len(g.Inbound(p)))},
+			},
+		})
+	}
+	for i, l := range g.Links {
+		doc.Graph.Edges = append(doc.Graph.Edges, gexfEdge{
+			ID:     fmt.Sprintf("e%d", i),
+			Source: ids[l.From],
+			Target: ids[l.To],
+			Label:  l.Text,
+			AttValues: []gexfAttValue{
+				{For: "text", Value: l.Text},
+				{For: "rel", Value: l.Rel},
+			},
+		})
+	}
+	return writeXML(w, doc)
+}
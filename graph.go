@@ -0,0 +1,116 @@
+package crawler
+
+import (
+	"sync"
+	"time"
+)
+
+// Graph is a crawled site: the unique pages, keyed by their normalised url,
+// and the links between them
+type Graph struct {
+	Root  *Page   // the page the crawl started from
+	Pages []*Page // in the order they were found
+	Links []*Link // in the order they were found
+
+	mu    sync.Mutex // protects the graph while crawling
+	byURL map[string]*Page
+	out   map[*Page][]*Link
+	in    map[*Page][]*Link
+}
+
+// Page represents a web page
+type Page struct {
+	URL   string // normalised url, unique within the graph
+	Depth int    // fewest clicks from the root page
+	Title string // the page's <title>
+
+	// fetch metadata, zero if the page wasn't fetched
+	StatusCode    int
+	FinalURL      string        // url after any redirects
+	ContentType   string        // media type without parameters, e.g. text/html
+	ContentLength int64         // -1 if unknown
+	ResponseTime  time.Duration // until the response headers were received
+	FetchedAt     time.Time
+	LastModified  time.Time // from the Last-Modified header, zero if not given
+
+	NotCrawled  string       // why the page wasn't fetched, e.g. disallowed by robots.txt or limit: max depth 3
+	Err         error        // why the page couldn't be crawled, if it failed
+	Diagnostics []Diagnostic // links on the page that were skipped, e.g. malformed urls
+}
+
+// Link is a directed link from one page to another. A page linking to the
+// same page twice has two links
+type Link struct {
+	From     *Page
+	To       *Page
+	Text     string // anchor text
+	Rel      string // the rel attribute, e.g. nofollow
+	Target   string // the target attribute, e.g. _blank
+	Hreflang string // the hreflang attribute, e.g. en-GB
+	Title    string // the title attribute
+	Element  string // the html element the link is in, e.g. a
+	Attr     string // the attribute holding the link, e.g. href
+}
+
+func newGraph() *Graph {
+	return &Graph{
+		byURL: make(map[string]*Page),
+		out:   make(map[*Page][]*Link),
+		in:    make(map[*Page][]*Link),
+	}
+}
+
+// add returns the page for the normalised url, creating it if it's new
+func (g *Graph) add(url string) (p *Page, created bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if p := g.byURL[url]; p != nil {
+		return p, false
+	}
+	p = &Page{URL: url}
+	g.byURL[url] = p
+	g.Pages = append(g.Pages, p)
+	return p, true
+}
+
+// link adds the link u found on one page to another page
+func (g *Graph) link(from, to *Page, u URL) *Link {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	l := &Link{
+		From:     from,
+		To:       to,
+		Text:     u.Description,
+		Rel:      u.Rel,
+		Target:   u.Target,
+		Hreflang: u.Hreflang,
+		Title:    u.Title,
+		Element:  u.Element,
+		Attr:     u.Attr,
+	}
+	g.Links = append(g.Links, l)
+	g.out[from] = append(g.out[from], l)
+	g.in[to] = append(g.in[to], l)
+	return l
+}
+
+// Page returns the page with the normalised url, or nil if there's none
+func (g *Graph) Page(url string) *Page {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.byURL[url]
+}
+
+// Outbound returns the links on p, in the order they appear on the page
+func (g *Graph) Outbound(p *Page) []*Link {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.out[p]
+}
+
+// Inbound returns the links pointing to p
+func (g *Graph) Inbound(p *Page) []*Link {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.in[p]
+}
@@ -0,0 +1,99 @@
+package crawler
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteGraphML writes the graph as GraphML, e.g. for NetworkX. Nodes have
+// url, status, depth, title and in_degree attributes, edges text and rel
+func WriteGraphML(w io.Writer, g *Graph) error {
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "url", For: "node", Name: "url", Type: "string"},
+			{ID: "status", For: "node", Name: "status", Type: "int"},
+			{ID: "depth", For: "node", Name: "depth", Type: "int"},
+			{ID: "title", For: "node", Name: "title", Type: "string"},
+			{ID: "in_degree", For: "node", Name: "in_degree", Type: "int"},
+			{ID: "text", For: "edge", Name: "text", Type: "string"},
+			{ID: "rel", For: "edge", Name: "rel", Type: "string"},
+		},
+		Graph: graphMLGraph{ID: "site", EdgeDefault: "directed"},
+	}
+
+	ids := nodeIDs(g)
+	for _, p := range g.Pages {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			ID: ids[p],
+			Data: []graphMLData{
+				{Key: "url", Value: p.URL},
+				{Key: "status", Value: strconv.Itoa(p.StatusCode)},
+				{Key: "depth", Value: strconv.Itoa(p.Depth)},
+				{Key: "title", Value: p.Title},
+				{Key: "in_degree", Value: strconv.Itoa(len(g.Inbound(p)))},
+			},
+		})
+	}
+	for i, l := range g.Links {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			ID:     fmt.Sprintf("e%d", i),
+			Source: ids[l.From],
+			Target: ids[l.To],
+			Data: []graphMLData{
+				{Key: "text", Value: l.Text},
+				{Key: "rel", Value: l.Rel},
+			},
+		})
+	}
+	return writeXML(w, doc)
+}
+
+// nodeIDs numbers the pages of g in the order they were found
+func nodeIDs(g *Graph) map[*Page]string {
+	ids := make(map[*Page]string, len(g.Pages))
+	for i, p := range g.Pages {
+		ids[p] = fmt.Sprintf("n%d", i)
+	}
+	return ids
+}
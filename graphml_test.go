@@ -0,0 +1,54 @@
+package crawler
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+)
+
+func TestWriteGraphML(t *testing.T) {
+	g := testDiagramGraph()
+	g.Root.Title = "Home"
+	var buf bytes.Buffer
+	if err := WriteGraphML(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	var doc graphML
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Graph.Nodes) != len(g.Pages) || len(doc.Graph.Edges) != len(g.Links) {
+		t.Fatalf("expect %d nodes and %d edges, got %d and %d", len(g.Pages), len(g.Links), len(doc.Graph.Nodes), len(doc.Graph.Edges))
+	}
+	data := make(map[string]string)
+	for _, d := range doc.Graph.Nodes[0].Data {
+		data[d.Key] = d.Value
+	}
+	if data["url"] != "http://example.com/" || data["title"] != "Home" || data["in_degree"] != "3" || data["status"] != "200" {
+		t.Errorf("expect home page attributes, got %v", data)
+	}
+	if e := doc.Graph.Edges[2]; e.Source != "n1" || e.Target != "n2" || e.Data[0].Value != "Post" {
+		t.Errorf("expect third edge to be blog -> post, got %+v", e)
+	}
+}
+
+func TestWriteGEXF(t *testing.T) {
+	g := testDiagramGraph()
+	var buf bytes.Buffer
+	if err := WriteGEXF(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	var doc gexf
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Graph.Nodes) != len(g.Pages) || len(doc.Graph.Edges) != len(g.Links) {
+		t.Fatalf("expect %d nodes and %d edges, got %d and %d", len(g.Pages), len(g.Links), len(doc.Graph.Nodes), len(doc.Graph.Edges))
+	}
+	if n := doc.Graph.Nodes[2]; n.Label != "http://example.com/blog/post" || n.AttValues[0].Value != "404" {
+		t.Errorf("expect post node with status 404, got %+v", n)
+	}
+	if e := doc.Graph.Edges[3]; e.Label != "Read more" {
+		t.Errorf("expect fourth edge labelled Read more, got %+v", e)
+	}
+}
@@ -0,0 +1,156 @@
+package crawler
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// JSONVersion is the version of the JSON schema. It changes only when a
+// field is removed or changes meaning; new fields may be added at any time
+const JSONVersion = 1
+
+// JSONGraph is the JSON form of a Graph:
+//
+//	{
+//	  "version": 1,
+//	  "root": "https://monzo.com/",
+//	  "pages": [{"url": "https://monzo.com/", "depth": 0, "status": 200, ...}],
+//	  "links": [{"from": "https://monzo.com/", "to": "https://monzo.com/about", "text": "About"}]
+//	}
+//
+// Pages and links are in the order they were found
+type JSONGraph struct {
+	Version int        `json:"version"`
+	Root    string     `json:"root"`
+	Pages   []JSONPage `json:"pages"`
+	Links   []JSONLink `json:"links"`
+}
+
+// JSONPage is the JSON form of a Page. Fetch metadata is left out if the page
+// wasn't fetched
+type JSONPage struct {
+	URL            string     `json:"url"`
+	Depth          int        `json:"depth"`
+	Title          string     `json:"title,omitempty"`
+	Status         int        `json:"status,omitempty"`
+	FinalURL       string     `json:"final_url,omitempty"`
+	ContentType    string     `json:"content_type,omitempty"`
+	ContentLength  *int64     `json:"content_length,omitempty"` // -1 if unknown
+	ResponseTimeMS float64    `json:"response_time_ms,omitempty"`
+	FetchedAt      *time.Time `json:"fetched_at,omitempty"`    // RFC 3339
+	LastModified   *time.Time `json:"last_modified,omitempty"` // RFC 3339
+	NotCrawled     string     `json:"not_crawled,omitempty"`
+	Error          string     `json:"error,omitempty"`
+
+	Diagnostics []JSONDiagnostic `json:"diagnostics,omitempty"`
+}
+
+// JSONDiagnostic is the JSON form of a Diagnostic, given on the page it's
+// about
+type JSONDiagnostic struct {
+	Raw     string `json:"raw"`
+	Element string `json:"element"`
+	Attr    string `json:"attr"`
+	Reason  string `json:"reason"`
+}
+
+// JSONLink is the JSON form of a Link, with pages given by their url
+type JSONLink struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Text     string `json:"text"`
+	Rel      string `json:"rel,omitempty"`
+	Target   string `json:"target,omitempty"`
+	Hreflang string `json:"hreflang,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Element  string `json:"element"`
+	Attr     string `json:"attr"`
+}
+
+// JSONLine is a page in a JSON Lines stream, written as soon as the page has
+// been visited: the JSONPage fields and the links found on the page
+type JSONLine struct {
+	JSONPage
+	Links []JSONLink `json:"links"`
+}
+
+// NewJSONGraph converts g to its JSON form
+func NewJSONGraph(g *Graph) *JSONGraph {
+	jg := &JSONGraph{
+		Version: JSONVersion,
+		Pages:   make([]JSONPage, 0, len(g.Pages)),
+		Links:   make([]JSONLink, 0, len(g.Links)),
+	}
+	if g.Root != nil {
+		jg.Root = g.Root.URL
+	}
+	for _, p := range g.Pages {
+		jg.Pages = append(jg.Pages, NewJSONPage(p))
+	}
+	for _, l := range g.Links {
+		jg.Links = append(jg.Links, NewJSONLink(l))
+	}
+	return jg
+}
+
+// NewJSONPage converts p to its JSON form
+func NewJSONPage(p *Page) JSONPage {
+	jp := JSONPage{
+		URL:            p.URL,
+		Depth:          p.Depth,
+		Title:          p.Title,
+		Status:         p.StatusCode,
+		FinalURL:       p.FinalURL,
+		ContentType:    p.ContentType,
+		ResponseTimeMS: float64(p.ResponseTime) / float64(time.Millisecond),
+		NotCrawled:     p.NotCrawled,
+	}
+	if p.StatusCode != 0 {
+		n := p.ContentLength
+		jp.ContentLength = &n
+	}
+	if !p.FetchedAt.IsZero() {
+		t := p.FetchedAt.UTC()
+		jp.FetchedAt = &t
+	}
+	if !p.LastModified.IsZero() {
+		t := p.LastModified.UTC()
+		jp.LastModified = &t
+	}
+	if p.Err != nil {
+		jp.Error = p.Err.Error()
+	}
+	for _, d := range p.Diagnostics {
+		jp.Diagnostics = append(jp.Diagnostics, JSONDiagnostic{Raw: d.Raw, Element: d.Element, Attr: d.Attr, Reason: d.Reason})
+	}
+	return jp
+}
+
+// NewJSONLink converts l to its JSON form
+func NewJSONLink(l *Link) JSONLink {
+	return JSONLink{
+		From:     l.From.URL,
+		To:       l.To.URL,
+		Text:     l.Text,
+		Rel:      l.Rel,
+		Target:   l.Target,
+		Hreflang: l.Hreflang,
+		Title:    l.Title,
+		Element:  l.Element,
+		Attr:     l.Attr,
+	}
+}
+
+// NewJSONLine converts p and the links found on it to a JSON Lines record
+func NewJSONLine(p *Page, links []*Link) JSONLine {
+	line := JSONLine{JSONPage: NewJSONPage(p), Links: make([]JSONLink, 0, len(links))}
+	for _, l := range links {
+		line.Links = append(line.Links, NewJSONLink(l))
+	}
+	return line
+}
+
+// MarshalJSON encodes g as a JSONGraph
+func (g *Graph) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewJSONGraph(g))
+}
@@ -0,0 +1,162 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	f := fixtures{
+		"http://example.com/":       htmlHome,
+		"http://example.com/about":  htmlAbout,
+		"http://example.com/career": htmlCareer,
+	}
+	g, err := New(WithFetcher(f)).Crawl("http://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var jg JSONGraph
+	if err := json.Unmarshal(b, &jg); err != nil {
+		t.Fatal(err)
+	}
+	if jg.Version != JSONVersion || jg.Root != "http://example.com/" {
+		t.Errorf("expect version %d and root http://example.com/, got %d %s", JSONVersion, jg.Version, jg.Root)
+	}
+	if len(jg.Pages) != len(g.Pages) || len(jg.Links) != len(g.Links) {
+		t.Fatalf("expect %d pages and %d links, got %d and %d", len(g.Pages), len(g.Links), len(jg.Pages), len(jg.Links))
+	}
+	if p := jg.Pages[0]; p.URL != "http://example.com/" || p.Status != 200 || p.FetchedAt == nil {
+		t.Errorf("expect root page to be fetched with 200, got %+v", p)
+	}
+	if l := jg.Links[1]; l.From != "http://example.com/" || l.To != "http://example.com/about" || l.Text != "about" {
+		t.Errorf("expect second link to be about, got %+v", l)
+	}
+}
+
+func TestJSONContentLength(t *testing.T) {
+	f := fixtures{
+		"http://example.com/":      `<a href="/empty">empty</a><img src="/logo.png">`,
+		"http://example.com/empty": "",
+	}
+	g, err := New(WithFetcher(f)).Crawl("http://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for u, expect := range map[string]string{
+		"http://example.com/empty":    `"content_length":0`,
+		"http://example.com/logo.png": "",
+	} {
+		b, err := json.Marshal(NewJSONPage(g.Page(u)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// a page that wasn't fetched has no content length, an empty one has 0
+		if got := strings.Contains(string(b), `"content_length"`); got != (expect != "") || !strings.Contains(string(b), expect) {
+			t.Errorf("expect %s to have content length %q, got %s", u, expect, b)
+		}
+	}
+}
+
+func TestJSONLines(t *testing.T) {
+	f := fixtures{
+		"http://example.com/":       htmlHome,
+		"http://example.com/about":  htmlAbout,
+		"http://example.com/career": htmlCareer,
+	}
+	var lines []JSONLine
+	c := New(WithFetcher(f), WithOnPage(func(p *Page, links []*Link) {
+		lines = append(lines, NewJSONLine(p, links))
+	}))
+	g, err := c.Crawl("http://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != len(g.Pages) {
+		t.Fatalf("expect a line per page, got %d lines for %d pages", len(lines), len(g.Pages))
+	}
+	for _, line := range lines {
+		if line.URL == "http://example.com/" && len(line.Links) != 3 {
+			t.Errorf("expect root page line with 3 links, got %d", len(line.Links))
+		}
+	}
+	b, err := json.Marshal(lines[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["url"] != "http://example.com/" || m["links"] == nil {
+		t.Errorf("expect page fields and links at the top level, got %s", b)
+	}
+}
+
+func TestJSONLinesEarly(t *testing.T) {
+	f := fixtures{
+		"http://example.com/":     `<a href="/a">a</a><a href="/b">b</a><a href="/slow">slow</a><a href="/hang">hang</a>`,
+		"http://example.com/a":    htmlCareer,
+		"http://example.com/b":    htmlCareer,
+		"http://example.com/slow": htmlCareer,
+	}
+	start := time.Now()
+	fetcher := FetcherFunc(func(ctx context.Context, url string) (*Response, error) {
+		switch url {
+		case "http://example.com/slow":
+			time.Sleep(300 * time.Millisecond)
+		case "http://example.com/hang":
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return f.Fetch(ctx, url)
+	})
+
+	var mu sync.Mutex
+	emitted := make(map[string]time.Duration)
+	var lines []JSONLine
+	c := New(WithFetcher(fetcher), WithOnPage(func(p *Page, links []*Link) {
+		mu.Lock()
+		defer mu.Unlock()
+		emitted[p.URL] = time.Since(start)
+		lines = append(lines, NewJSONLine(p, links))
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), 600*time.Millisecond)
+	defer cancel()
+	g, err := c.CrawlContext(ctx, "http://example.com")
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expect error to be %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	// pages before /slow in the level don't wait for it
+	for _, u := range []string{"http://example.com/a", "http://example.com/b"} {
+		if d, ok := emitted[u]; !ok || d > 200*time.Millisecond {
+			t.Errorf("expect %s to be emitted as soon as it's fetched, got %v", u, d)
+		}
+	}
+	if d := emitted["http://example.com/slow"]; d < 300*time.Millisecond {
+		t.Errorf("expect /slow to be emitted once fetched, got %v", d)
+	}
+	// a page never fetched isn't emitted, and is marked in the graph
+	if _, ok := emitted["http://example.com/hang"]; ok {
+		t.Error("expect /hang not to be emitted")
+	}
+	for _, line := range lines {
+		if line.Status == 0 && line.NotCrawled == "" && line.Error == "" {
+			t.Errorf("expect every emitted page to be fetched or say why not, got %+v", line)
+		}
+	}
+	if p := g.Page("http://example.com/hang"); p.NotCrawled != "crawl stopped" {
+		t.Errorf("expect /hang to be not crawled because the crawl stopped, got %q", p.NotCrawled)
+	}
+}
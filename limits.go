@@ -0,0 +1,73 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"sync"
+)
+
+// WithMaxDepth stops following links more than n clicks away from the seed.
+// Zero means no limit
+func WithMaxDepth(n int) Option {
+	return func(c *Crawler) { c.maxDepth = n }
+}
+
+// WithMaxPages stops fetching pages once n pages have been fetched. Zero
+// means no limit
+func WithMaxPages(n int) Option {
+	return func(c *Crawler) { c.maxPages = n }
+}
+
+// WithMaxPagesPerPrefix fetches at most n pages whose paths share their first
+// segments path segments, e.g. n pages under /events with segments 1. It keeps
+// calendars and other endless url spaces in check. Zero n means no limit
+func WithMaxPagesPerPrefix(n, segments int) Option {
+	return func(c *Crawler) {
+		c.maxPrefixPages = n
+		c.prefixSegments = segments
+	}
+}
+
+// limits counts the pages fetched against the limits of a crawl
+type limits struct {
+	maxDepth       int
+	maxPages       int
+	maxPrefixPages int
+	prefixSegments int
+
+	mu       sync.Mutex
+	pages    int
+	prefixes map[string]int // pages fetched by host and path prefix
+}
+
+func newLimits(c *Crawler) *limits {
+	return &limits{
+		maxDepth:       c.maxDepth,
+		maxPages:       c.maxPages,
+		maxPrefixPages: c.maxPrefixPages,
+		prefixSegments: c.prefixSegments,
+		prefixes:       make(map[string]int),
+	}
+}
+
+// take counts page at u as fetched, unless that would exceed a limit. It
+// returns why the page isn't crawled, e.g. "limit: max depth 3", or "" if it
+// can be
+func (l *limits) take(page *Page, u *url.URL) string {
+	if l.maxDepth > 0 && page.Depth > l.maxDepth {
+		return fmt.Sprintf("limit: max depth %d", l.maxDepth)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.maxPages > 0 && l.pages >= l.maxPages {
+		return fmt.Sprintf("limit: max %d pages", l.maxPages)
+	}
+	prefix := u.Host + clusterName(u.Path, l.prefixSegments)
+	if l.maxPrefixPages > 0 && l.prefixes[prefix] >= l.maxPrefixPages {
+		return fmt.Sprintf("limit: max %d pages under %s", l.maxPrefixPages, prefix)
+	}
+	l.pages++
+	l.prefixes[prefix]++
+	return ""
+}
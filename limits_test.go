@@ -0,0 +1,59 @@
+package crawler
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCrawlLimits(t *testing.T) {
+	f := fixtures{
+		"http://example.com/":         `<a href="/events/1">1</a><a href="/events/2">2</a><a href="/events/3">3</a><a href="/about">about</a>`,
+		"http://example.com/events/1": `<a href="/events/1/more">more</a>`,
+		"http://example.com/events/2": htmlCareer,
+		"http://example.com/events/3": htmlCareer,
+		"http://example.com/about":    htmlCareer,
+	}
+	limited := func(g *Graph) (crawled int, notCrawled []string) {
+		for _, p := range g.Pages {
+			if p.StatusCode != 0 {
+				crawled++
+			} else if strings.HasPrefix(p.NotCrawled, "limit: ") {
+				notCrawled = append(notCrawled, p.NotCrawled)
+			}
+		}
+		return crawled, notCrawled
+	}
+
+	g, err := New(WithFetcher(f), WithMaxDepth(1)).Crawl("http://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	more := g.Page("http://example.com/events/1/more")
+	if more == nil || more.StatusCode != 0 || more.NotCrawled != "limit: max depth 1" {
+		t.Errorf("expect /events/1/more to be recorded as not crawled, got %+v", more)
+	}
+
+	g, err = New(WithFetcher(f), WithMaxPages(3)).Crawl("http://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crawled, notCrawled := limited(g); crawled != 3 || len(notCrawled) != len(g.Pages)-3 || notCrawled[0] != "limit: max 3 pages" {
+		t.Errorf("expect 3 pages crawled and the rest not crawled, got %d and %v", crawled, notCrawled)
+	}
+	if p := g.Page("http://example.com/events/2"); p.StatusCode != 200 {
+		t.Errorf("expect the first 3 pages found to be crawled, got %+v", p)
+	}
+
+	g, err = New(WithFetcher(f), WithMaxPagesPerPrefix(2, 1)).Crawl("http://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crawled, notCrawled := limited(g); crawled != 4 || len(notCrawled) != 2 || notCrawled[0] != "limit: max 2 pages under example.com/events" {
+		t.Errorf("expect the root, /about and 2 pages under /events crawled, got %d and %v", crawled, notCrawled)
+	}
+	for _, u := range []string{"http://example.com/about", "http://example.com/events/1", "http://example.com/events/2"} {
+		if p := g.Page(u); p.StatusCode != 200 {
+			t.Errorf("expect %s to be crawled, got %d", u, p.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package crawler
+
+import (
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Normalizer turns a url into the key identifying its page, so that urls
+// differing only in form count as one page. The key isn't fetched; a page is
+// fetched at the first url found for it. It always lowercases the scheme
+// and host, drops default ports and fragments, decodes percent-escaped
+// unreserved characters and sorts query params. The fields add more rules
+type Normalizer struct {
+	DropQuery  bool     // drop the whole query
+	KeepParams []string // if not empty, only keep the query params matching these globs, e.g. page
+	DropParams []string // drop the query params matching these globs, e.g. utm_*
+
+	TrimTrailingSlash bool   // treat /a/ as /a
+	Scheme            string // if set, http and https urls get this scheme, so both count as one page
+}
+
+// WithNormalizer sets the rules deciding when two urls are the same page
+func WithNormalizer(n Normalizer) Option {
+	return func(c *Crawler) { c.normalizer = n }
+}
+
+// Normalize returns the normalised form of u
+func (n *Normalizer) Normalize(u *url.URL) string {
+	scheme := strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]" // ipv6
+	}
+	if port := u.Port(); port != "" && !(port == "80" && scheme == "http" || port == "443" && scheme == "https") {
+		host += ":" + port
+	}
+	if n.Scheme != "" && (scheme == "http" || scheme == "https") {
+		scheme = n.Scheme
+	}
+
+	p := normalizeEscapes(u.EscapedPath())
+	if p == "" {
+		p = "/"
+	}
+	if n.TrimTrailingSlash && len(p) > 1 {
+		p = strings.TrimRight(p, "/")
+		if p == "" {
+			p = "/"
+		}
+	}
+
+	k := scheme + "://" + host + p
+	if q := n.normalizeQuery(u.RawQuery); q != "" {
+		k += "?" + q
+	}
+	return k
+}
+
+func (n *Normalizer) normalizeQuery(raw string) string {
+	if n.DropQuery || raw == "" {
+		return ""
+	}
+	// params are kept as written, only their escapes normalised, so that a
+	// param without a value doesn't get an =
+	type param struct{ name, text string }
+	var params []param
+	for _, text := range strings.Split(raw, "&") {
+		if text == "" {
+			continue
+		}
+		text = normalizeEscapes(text)
+		name := strings.SplitN(text, "=", 2)[0]
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if len(n.KeepParams) > 0 && !matchAny(n.KeepParams, name) || matchAny(n.DropParams, name) {
+			continue
+		}
+		params = append(params, param{name, text})
+	}
+	// values of the same name keep their order
+	sort.SliceStable(params, func(i, j int) bool { return params[i].name < params[j].name })
+	texts := make([]string, len(params))
+	for i, p := range params {
+		texts[i] = p.text
+	}
+	return strings.Join(texts, "&")
+}
+
+func matchAny(globs []string, name string) bool {
+	for _, g := range globs {
+		if ok, _ := path.Match(g, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeEscapes decodes percent-escapes of unreserved characters, which
+// mean the same escaped or not, and uppercases the others
+func normalizeEscapes(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]) {
+			c := unhex(s[i+1])<<4 | unhex(s[i+2])
+			if isUnreserved(c) {
+				b.WriteByte(c)
+			} else {
+				b.WriteString(strings.ToUpper(s[i : i+3]))
+			}
+			i += 2
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	}
+	return c - 'A' + 10
+}
@@ -0,0 +1,97 @@
+package crawler
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		n      Normalizer
+		url    string
+		expect string
+	}{
+		{Normalizer{}, "http://example.com", "http://example.com/"},
+		{Normalizer{}, "HTTP://Example.COM:80/About", "http://example.com/About"},
+		{Normalizer{}, "https://example.com:443/", "https://example.com/"},
+		{Normalizer{}, "http://example.com:8080/", "http://example.com:8080/"},
+		{Normalizer{}, "http://[::1]:8080/", "http://[::1]:8080/"},
+		{Normalizer{}, "http://example.com/a#top", "http://example.com/a"},
+		{Normalizer{}, "http://example.com/%7euser/%41%2f%3f", "http://example.com/~user/A%2F%3F"},
+		{Normalizer{}, "http://example.com/a%20b", "http://example.com/a%20b"},
+		{Normalizer{}, "http://example.com/search?q=b&page=2&q=a", "http://example.com/search?page=2&q=b&q=a"},
+		{Normalizer{}, "http://example.com/s?foo&a=%7e&&b=", "http://example.com/s?a=~&b=&foo"},
+		{Normalizer{DropQuery: true}, "http://example.com/search?q=a", "http://example.com/search"},
+		{Normalizer{DropParams: []string{"utm_*"}}, "http://example.com/?utm_source=x&q=a&utm_medium=y", "http://example.com/?q=a"},
+		{Normalizer{DropParams: []string{"utm_*"}}, "http://example.com/?utm_source=x", "http://example.com/"},
+		{Normalizer{KeepParams: []string{"page"}}, "http://example.com/list?sort=asc&page=2", "http://example.com/list?page=2"},
+		{Normalizer{TrimTrailingSlash: true}, "http://example.com/blog/", "http://example.com/blog"},
+		{Normalizer{TrimTrailingSlash: true}, "http://example.com/", "http://example.com/"},
+		{Normalizer{Scheme: "https"}, "http://example.com:80/", "https://example.com/"},
+		{Normalizer{Scheme: "http"}, "HTTPS://example.com/a", "http://example.com/a"},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse(tt.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := tt.n.Normalize(u); got != tt.expect {
+			t.Errorf("%+v %s: expect %s, got %s", tt.n, tt.url, tt.expect, got)
+		}
+	}
+}
+
+func TestCrawlQuery(t *testing.T) {
+	f := fixtures{
+		"http://example.com/": `<a href="/search?q=a">a</a><a href="/search?q=b&utm_source=home">b</a>
+			<a href="/search?utm_source=nav&q=b">b</a><a href="/Search?q=a#results">a</a>`,
+		"http://example.com/search?q=a": htmlCareer,
+		"http://example.com/search?q=b": htmlCareer,
+		"http://example.com/Search?q=a": htmlCareer,
+	}
+	g, err := New(WithFetcher(f), WithNormalizer(Normalizer{DropParams: []string{"utm_*"}})).Crawl("http://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Pages) != 4 {
+		t.Errorf("expect the root and 3 search pages, got %d pages", len(g.Pages))
+	}
+	if p := g.Page("http://example.com/search?q=b"); p == nil || len(g.Inbound(p)) != 2 {
+		t.Errorf("expect search for b to be linked twice")
+	}
+}
+
+func TestCrawlFetchesLinksAsFound(t *testing.T) {
+	f := fixtures{
+		"http://example.com/":             `<a href="/blog/#top">blog</a><a href="/blog">blog</a><a href="/s?foo">s</a><a href="http://user@example.com/private">p</a>`,
+		"http://example.com/blog/":        htmlCareer,
+		"http://example.com/s?foo":        htmlCareer,
+		"http://user@example.com/private": htmlCareer,
+	}
+	var mu sync.Mutex
+	var fetched []string
+	fetcher := FetcherFunc(func(ctx context.Context, url string) (*Response, error) {
+		mu.Lock()
+		fetched = append(fetched, url)
+		mu.Unlock()
+		return f.Fetch(ctx, url)
+	})
+	n := Normalizer{TrimTrailingSlash: true}
+	g, err := New(WithFetcher(fetcher), WithIgnoreRobots(true), WithNormalizer(n)).Crawl("http://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(fetched)
+	expect := "http://example.com/ http://example.com/blog/ http://example.com/s?foo http://user@example.com/private"
+	if strings.Join(fetched, " ") != expect {
+		t.Errorf("expect links fetched as found, %s, got %v", expect, fetched)
+	}
+	// /blog/ and /blog are one page, keyed by the normalised url
+	if p := g.Page("http://example.com/blog"); p == nil || len(g.Inbound(p)) != 2 || p.StatusCode != 200 {
+		t.Errorf("expect /blog/ and /blog to be one page, got %+v", p)
+	}
+}
@@ -0,0 +1,281 @@
+package crawler
+
+import (
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/html"
+)
+
+// URL is a link found on a page
+type URL struct {
+	URI         string // absolute url, as found on the page
+	Description string // the anchor text
+	Rel         string // the rel attribute, e.g. nofollow
+	Target      string // the target attribute, e.g. _blank
+	Hreflang    string // the hreflang attribute, e.g. en-GB
+	Title       string // the title attribute
+	Element     string // the html element the link is in, e.g. a
+	Attr        string // the attribute holding the link, e.g. href
+}
+
+// Diagnostic is a problem with a link on a page, such as a url that can't be
+// parsed. The link is skipped
+type Diagnostic struct {
+	Source  string // url of the page the link is on
+	Raw     string // the link as written in the page
+	Element string // the html element the link is in, e.g. a
+	Attr    string // the attribute holding the link, e.g. href
+	Reason  string // what's wrong with it
+}
+
+// document is what parse finds in a page
+type document struct {
+	Title       string
+	Links       []URL
+	Diagnostics []Diagnostic
+}
+
+// linkAttrs lists the attributes holding links for each element
+var linkAttrs = map[string][]string{
+	"a":      {"href"},
+	"area":   {"href"},
+	"link":   {"href"},
+	"iframe": {"src"},
+	"frame":  {"src"},
+	"form":   {"action"},
+	"img":    {"src", "srcset"},
+	"script": {"src"},
+	"meta":   {"content"}, // only <meta http-equiv="refresh">
+}
+
+// DefaultFollow lists the elements whose links are followed by default: the
+// ones leading to other pages
+var DefaultFollow = []string{"a", "area", "frame", "iframe", "meta"}
+
+// parse reads the document at u from r and returns the title and all links
+// from r. Links are resolved against the document's first
+// <base href>, or u if there's none. Links that can't be parsed are returned
+// as diagnostics
+func parse(u *url.URL, r io.Reader) (*document, error) {
+	root, err := html.Parse(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse html")
+	}
+	doc := &document{}
+	// diagnose records a link that can't be parsed
+	diagnose := func(n *html.Node, attr, raw string, err error) {
+		if uerr, ok := err.(*url.Error); ok {
+			err = uerr.Err
+		}
+		doc.Diagnostics = append(doc.Diagnostics, Diagnostic{
+			Source:  u.String(),
+			Raw:     raw,
+			Element: n.Data,
+			Attr:    attr,
+			Reason:  err.Error(),
+		})
+	}
+
+	base := u
+	if n := findBase(root); n != nil {
+		raw := attrValue(n, "href")
+		if href, err := url.Parse(strings.TrimSpace(raw)); err != nil {
+			diagnose(n, "href", raw, err)
+		} else {
+			base = u.ResolveReference(href)
+		}
+	}
+
+	add := func(n *html.Node, attr, raw string) {
+		u1, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil {
+			diagnose(n, attr, raw, err)
+			return
+		}
+		resolved := base.ResolveReference(u1)
+
+		link := URL{
+			URI:      resolved.String(),
+			Rel:      attrValue(n, "rel"),
+			Target:   attrValue(n, "target"),
+			Hreflang: attrValue(n, "hreflang"),
+			Title:    attrValue(n, "title"),
+			Element:  n.Data,
+			Attr:     attr,
+		}
+		if n.Data == "a" || n.Data == "area" {
+			link.Description = anchorText(n)
+		}
+		// only links to web pages, not e.g. mailto: or javascript:
+		if resolved.Scheme == "http" || resolved.Scheme == "https" {
+			doc.Links = append(doc.Links, link)
+		}
+	}
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "title" && doc.Title == "" && n.FirstChild != nil {
+			doc.Title = strings.TrimSpace(n.FirstChild.Data)
+		}
+		if n.Type == html.ElementNode {
+			for _, attr := range linkAttrs[n.Data] {
+				val, ok := attrLookup(n, attr)
+				if !ok {
+					continue
+				}
+				switch {
+				case attr == "srcset":
+					for _, raw := range srcset(val) {
+						add(n, attr, raw)
+					}
+				case n.Data == "meta":
+					if raw, ok := metaRefresh(n, val); ok {
+						add(n, attr, raw)
+					}
+				default:
+					add(n, attr, val)
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+	return doc, nil
+}
+
+// findBase returns the first <base href> in the document, which links are
+// resolved against instead of the document's url
+func findBase(n *html.Node) *html.Node {
+	if n.Type == html.ElementNode && n.Data == "base" {
+		if _, ok := attrLookup(n, "href"); ok {
+			return n
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if b := findBase(c); b != nil {
+			return b
+		}
+	}
+	return nil
+}
+
+// anchorText returns the text a link shows: the visible text in it with
+// whitespace collapsed, or else its title, aria-label or the alt text of its
+// images
+func anchorText(n *html.Node) string {
+	var b strings.Builder
+	var alt []string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			b.WriteString(n.Data)
+		case html.ElementNode:
+			switch n.Data {
+			case "script", "style", "template", "noscript":
+				return
+			case "br":
+				b.WriteString(" ")
+			case "img":
+				alt = append(alt, attrValue(n, "alt"))
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+
+	candidates := []string{b.String(), attrValue(n, "title"), attrValue(n, "aria-label"), strings.Join(alt, " ")}
+	if n.Data == "area" {
+		candidates[0] = attrValue(n, "alt")
+	}
+	for _, text := range candidates {
+		if text = strings.Join(strings.Fields(text), " "); text != "" {
+			return text
+		}
+	}
+	return ""
+}
+
+func attrLookup(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func attrValue(n *html.Node, key string) string {
+	v, _ := attrLookup(n, key)
+	return v
+}
+
+// srcset returns the urls in an img srcset, e.g. "a.png 1x, b.png 2x". As in
+// the html spec, a url runs up to whitespace and may contain commas, except
+// trailing ones, and its descriptors run up to the next comma outside
+// parentheses
+func srcset(val string) []string {
+	isSpace := func(c byte) bool { return strings.IndexByte(" \t\n\f\r", c) >= 0 }
+	var urls []string
+	i := 0
+	for {
+		for i < len(val) && (isSpace(val[i]) || val[i] == ',') {
+			i++
+		}
+		if i == len(val) {
+			return urls
+		}
+		start := i
+		for i < len(val) && !isSpace(val[i]) {
+			i++
+		}
+		u := val[start:i]
+		if trimmed := strings.TrimRight(u, ","); trimmed != u {
+			// a trailing comma ends the candidate, there are no descriptors
+			urls = append(urls, trimmed)
+			continue
+		}
+		urls = append(urls, u)
+
+		parens := false
+		for ; i < len(val) && (val[i] != ',' || parens); i++ {
+			switch val[i] {
+			case '(':
+				parens = true
+			case ')':
+				parens = false
+			}
+		}
+	}
+}
+
+// metaRefresh returns the url of <meta http-equiv="refresh" content="5; url=/next">
+func metaRefresh(n *html.Node, content string) (string, bool) {
+	if !strings.EqualFold(attrValue(n, "http-equiv"), "refresh") {
+		return "", false
+	}
+	i := strings.Index(content, ";")
+	if i < 0 {
+		i = strings.Index(content, ",")
+	}
+	if i < 0 {
+		return "", false
+	}
+	rest := strings.TrimSpace(content[i+1:])
+	if len(rest) >= 4 && strings.EqualFold(rest[:3], "url") {
+		rest = strings.TrimSpace(rest[3:])
+		if !strings.HasPrefix(rest, "=") {
+			return "", false
+		}
+		rest = strings.TrimSpace(rest[1:])
+	}
+	rest = strings.Trim(rest, `'"`)
+	return rest, rest != ""
+}
@@ -0,0 +1,324 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// htmlTitled is htmlHome with a title and a rel attribute
+const htmlTitled = `
+<!DOCTYPE html>
+<html>
+<head><title>Home</title></head>
+<a href="/">home</a>
+<a href="/about">about</a>
+<a href="/products" rel="nofollow">products</a>
+<a href="https://google.com">google</a>
+<body>
+</body>
+</html>
+`
+
+func TestParse(t *testing.T) {
+	u, err := url.Parse("http://example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := parse(u, strings.NewReader(htmlTitled))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Title != "Home" {
+		t.Errorf("expect title to be Home, got %s", doc.Title)
+	}
+	links := doc.Links
+
+	expects := []URL{
+		{URI: "http://example.com/", Description: "home", Element: "a"},
+		{URI: "http://example.com/about", Description: "about", Element: "a"},
+		{URI: "http://example.com/products", Description: "products", Rel: "nofollow", Element: "a"},
+		{URI: "https://google.com", Description: "google", Element: "a"},
+	}
+
+	if len(links) != len(expects) {
+		t.Fatalf("expect sublinks to have length of %d, got %d", len(expects), len(links))
+	}
+
+	for i := range expects {
+		if expects[i].URI != links[i].URI {
+			t.Errorf("expecting link uri to be %s, got %s", expects[i].URI, links[i].URI)
+		}
+		if expects[i].Description != links[i].Description {
+			t.Errorf("expecting link description to be %s, got %s", expects[i].Description, links[i].Description)
+		}
+		if expects[i].Element != links[i].Element {
+			t.Errorf("expecting link element to be %s, got %s", expects[i].Element, links[i].Element)
+		}
+		if expects[i].Rel != links[i].Rel {
+			t.Errorf("expecting link rel to be %s, got %s", expects[i].Rel, links[i].Rel)
+		}
+	}
+}
+
+const htmlElements = `
+<!DOCTYPE html>
+<html>
+<head>
+<meta http-equiv="refresh" content="5; URL='/next'">
+<link rel="stylesheet" href="/style.css">
+<script src="/app.js"></script>
+</head>
+<body>
+<img src="/logo.png" srcset="/logo-2x.png 2x, /logo-3x.png 3x">
+<map><area href="/area" alt="area"></map>
+<iframe src="/frame"></iframe>
+<form action="/search"></form>
+</body>
+</html>
+`
+
+func TestParseElements(t *testing.T) {
+	u, err := url.Parse("http://example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := parse(u, strings.NewReader(htmlElements))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expects := []URL{
+		{URI: "http://example.com/next", Element: "meta", Attr: "content"},
+		{URI: "http://example.com/style.css", Element: "link", Attr: "href", Rel: "stylesheet"},
+		{URI: "http://example.com/app.js", Element: "script", Attr: "src"},
+		{URI: "http://example.com/logo.png", Element: "img", Attr: "src"},
+		{URI: "http://example.com/logo-2x.png", Element: "img", Attr: "srcset"},
+		{URI: "http://example.com/logo-3x.png", Element: "img", Attr: "srcset"},
+		{URI: "http://example.com/area", Element: "area", Attr: "href"},
+		{URI: "http://example.com/frame", Element: "iframe", Attr: "src"},
+		{URI: "http://example.com/search", Element: "form", Attr: "action"},
+	}
+	if len(doc.Links) != len(expects) {
+		t.Fatalf("expect %d links, got %d: %v", len(expects), len(doc.Links), doc.Links)
+	}
+	for i, expect := range expects {
+		l := doc.Links[i]
+		if l.URI != expect.URI || l.Element != expect.Element || l.Attr != expect.Attr || l.Rel != expect.Rel {
+			t.Errorf("expect link %d to be %+v, got %+v", i, expect, l)
+		}
+	}
+}
+
+func TestCrawlFollow(t *testing.T) {
+	f := fixtures{
+		"http://example.com/":      htmlElements,
+		"http://example.com/next":  htmlCareer,
+		"http://example.com/area":  htmlCareer,
+		"http://example.com/frame": htmlCareer,
+	}
+	crawled := func(g *Graph) int {
+		n := 0
+		for _, p := range g.Pages {
+			if p.StatusCode != 0 {
+				n++
+			}
+		}
+		return n
+	}
+	g, err := New(WithFetcher(f)).Crawl("http://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Pages) != 10 || crawled(g) != 4 {
+		t.Errorf("expect the root, meta refresh, area and iframe pages crawled of 10, got %d of %d", crawled(g), len(g.Pages))
+	}
+	// links that aren't followed are kept, to pages that aren't fetched
+	if p := g.Page("http://example.com/logo.png"); p == nil || p.NotCrawled != "not followed" || len(g.Inbound(p)) != 1 {
+		t.Errorf("expect the logo to be linked but not followed, got %+v", p)
+	}
+
+	g, err = New(WithFetcher(f), WithFollow("img")).Crawl("http://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crawled(g) != 4 || g.Page("http://example.com/logo-2x.png").StatusCode == 0 {
+		t.Errorf("expect the root and 3 images crawled, got %d", crawled(g))
+	}
+	if p := g.Page("http://example.com/next"); p == nil || p.NotCrawled != "not followed" {
+		t.Errorf("expect the meta refresh not followed, got %+v", p)
+	}
+
+	// a page is fetched if any link to it is followed
+	f = fixtures{
+		"http://example.com/":      `<img src="/photo"><a href="/about">about</a>`,
+		"http://example.com/about": `<a href="/photo">photo</a>`,
+		"http://example.com/photo": htmlCareer,
+	}
+	var pages []string
+	onPage := func(p *Page, links []*Link) { pages = append(pages, p.URL) }
+	g, err = New(WithFetcher(f), WithOnPage(onPage)).Crawl("http://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := g.Page("http://example.com/photo"); p == nil || p.StatusCode != 200 || p.Depth != 2 || len(g.Inbound(p)) != 2 {
+		t.Errorf("expect the photo crawled at depth 2, got %+v", p)
+	}
+	if len(pages) != 3 {
+		t.Errorf("expect each page passed to OnPage once, got %v", pages)
+	}
+}
+
+func TestParseBase(t *testing.T) {
+	tests := []struct {
+		page   string
+		html   string
+		expect []string
+	}{
+		{
+			"http://example.com/blog/post",
+			`<a href="other">x</a><a href="../about">x</a><a href="/">x</a>`,
+			[]string{"http://example.com/blog/other", "http://example.com/about", "http://example.com/"},
+		},
+		{
+			"http://example.com/blog/",
+			`<a href="other">x</a>`,
+			[]string{"http://example.com/blog/other"},
+		},
+		{
+			"http://example.com/blog/post",
+			`<head><base target="_blank"><base href="/docs/"><base href="/ignored/"></head><a href="x">x</a><a href="/y">x</a>`,
+			[]string{"http://example.com/docs/x", "http://example.com/y"},
+		},
+		{
+			// only the first base counts, even after the links
+			"http://example.com/blog/post",
+			`<a href="x">x</a><base href="../docs/">`,
+			[]string{"http://example.com/docs/x"},
+		},
+		{
+			"http://example.com/blog/post",
+			`<base href="http://other.com/"><a href="x">x</a>`,
+			[]string{"http://other.com/x"},
+		},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse(tt.page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc, err := parse(u, strings.NewReader(tt.html))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, l := range doc.Links {
+			got = append(got, l.URI)
+		}
+		if strings.Join(got, " ") != strings.Join(tt.expect, " ") {
+			t.Errorf("%s %s: expect links %v, got %v", tt.page, tt.html, tt.expect, got)
+		}
+	}
+}
+
+func TestParseAnchorText(t *testing.T) {
+	tests := []struct {
+		html   string
+		expect URL
+	}{
+		{`<a href="/a">home</a>`, URL{Description: "home"}},
+		{`<a href="/a"><span>Download</span> the <abbr>Monzo</abbr>
+			app on   Android</a>`, URL{Description: "Download the Monzo app on Android"}},
+		{`<a href="/a">line<br>break<script>var x</script></a>`, URL{Description: "line break"}},
+		{`<a href="/a" title="Home page"> </a>`, URL{Description: "Home page", Title: "Home page"}},
+		{`<a href="/a" aria-label="Home"><svg></svg></a>`, URL{Description: "Home"}},
+		{`<a href="/a"><img src="/logo.png" alt="Monzo logo"></a>`, URL{Description: "Monzo logo"}},
+		{`<map><area href="/a" alt="Area"></map>`, URL{Description: "Area"}},
+		{`<a href="/a" rel="alternate" target="_blank" hreflang="en-GB">English</a>`,
+			URL{Description: "English", Rel: "alternate", Target: "_blank", Hreflang: "en-GB"}},
+	}
+	u, err := url.Parse("http://example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		doc, err := parse(u, strings.NewReader(tt.html))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(doc.Links) == 0 {
+			t.Fatalf("%s: expect a link", tt.html)
+		}
+		l := doc.Links[0]
+		if l.Description != tt.expect.Description || l.Rel != tt.expect.Rel || l.Target != tt.expect.Target ||
+			l.Hreflang != tt.expect.Hreflang || l.Title != tt.expect.Title {
+			t.Errorf("%s: expect %+v, got %+v", tt.html, tt.expect, l)
+		}
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	u, err := url.Parse("http://example.com/blog/post")
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := `<base href="%zz"><a href="http://[::1">bad</a><a href="/a%">bad</a><a href="ok">ok</a>`
+	doc, err := parse(u, strings.NewReader(html))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Links) != 1 || doc.Links[0].URI != "http://example.com/blog/ok" {
+		t.Errorf("expect only the ok link, resolved against the page, got %v", doc.Links)
+	}
+	expects := []Diagnostic{
+		{Source: u.String(), Raw: "%zz", Element: "base", Attr: "href", Reason: `invalid URL escape "%zz"`},
+		{Source: u.String(), Raw: "http://[::1", Element: "a", Attr: "href", Reason: "missing ']' in host"},
+		{Source: u.String(), Raw: "/a%", Element: "a", Attr: "href", Reason: `invalid URL escape "%"`},
+	}
+	if len(doc.Diagnostics) != len(expects) {
+		t.Fatalf("expect %d diagnostics, got %v", len(expects), doc.Diagnostics)
+	}
+	for i, expect := range expects {
+		if doc.Diagnostics[i] != expect {
+			t.Errorf("expect diagnostic %d to be %+v, got %+v", i, expect, doc.Diagnostics[i])
+		}
+	}
+}
+
+func TestCrawlMalformed(t *testing.T) {
+	f := fixtures{
+		"http://example.com/":       `<a href="http://[::1">bad</a><a href="/career">career</a>`,
+		"http://example.com/career": htmlCareer,
+	}
+	g, err := New(WithFetcher(f)).Crawl("http://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Pages) != 2 {
+		t.Errorf("expect the crawl to carry on past the malformed link, got %d pages", len(g.Pages))
+	}
+	if d := g.Root.Diagnostics; len(d) != 1 || d[0].Source != "http://example.com/" || d[0].Raw != "http://[::1" {
+		t.Errorf("expect the malformed link on the root page, got %v", d)
+	}
+}
+
+func TestSrcset(t *testing.T) {
+	tests := []struct {
+		val    string
+		expect []string
+	}{
+		{"a.png", []string{"a.png"}},
+		{"a.png 1x, b.png 2x", []string{"a.png", "b.png"}},
+		{" a.png  1x ,b.png\t2x, ", []string{"a.png", "b.png"}},
+		{"https://res.cloudinary.com/x/w_100,h_100/a.jpg 1x, https://res.cloudinary.com/x/w_200,h_200/a.jpg 2x",
+			[]string{"https://res.cloudinary.com/x/w_100,h_100/a.jpg", "https://res.cloudinary.com/x/w_200,h_200/a.jpg"}},
+		{"a.png,b.png 2x", []string{"a.png,b.png"}}, // one url, as in browsers
+		{"a.png 100w (foo, bar), b.png 200w", []string{"a.png", "b.png"}},
+		{", ,", nil},
+	}
+	for _, tt := range tests {
+		if got := srcset(tt.val); strings.Join(got, " ") != strings.Join(tt.expect, " ") {
+			t.Errorf("%q: expect %q, got %q", tt.val, tt.expect, got)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package crawler
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+)
+
+// reportData is what the report template renders
+type reportData struct {
+	Root      string
+	Generated time.Time
+	Pages     []*reportPage
+	Links     int
+	Broken    []*Link
+	Malformed []Diagnostic
+	Tree      *reportNode
+}
+
+type reportPage struct {
+	*Page
+	ID       string
+	Status   string
+	Inbound  []reportLink // the pages linking here
+	Outbound []reportLink // the pages linked to
+}
+
+type reportLink struct {
+	Page *reportPage
+	Text string
+}
+
+// reportNode is a page in the site tree. Each page is under the page it was
+// first reached from, so it appears once
+type reportNode struct {
+	Page     *reportPage
+	Children []*reportNode
+}
+
+// pageStatus describes how crawling a page went
+func pageStatus(p *Page) string {
+	switch {
+	case p.Err != nil:
+		return "error: " + p.Err.Error()
+	case p.NotCrawled != "":
+		return p.NotCrawled
+	case p.StatusCode == 0:
+		return "not crawled"
+	}
+	return fmt.Sprint(p.StatusCode)
+}
+
+// broken reports whether a link to p is broken
+func broken(p *Page) bool {
+	return p.Err != nil || p.StatusCode >= 400
+}
+
+// WriteHTMLReport writes a self contained html page reporting on the crawl:
+// a searchable, sortable table of pages, the broken and malformed links, a
+// collapsible tree of the site and the inbound and outbound links of each page
+func WriteHTMLReport(w io.Writer, g *Graph) error {
+	data := &reportData{Generated: time.Now().UTC(), Links: len(g.Links)}
+	pages := make(map[*Page]*reportPage, len(g.Pages))
+	for i, p := range g.Pages {
+		rp := &reportPage{Page: p, ID: fmt.Sprintf("p%d", i), Status: pageStatus(p)}
+		pages[p] = rp
+		data.Pages = append(data.Pages, rp)
+	}
+	for _, rp := range data.Pages {
+		for _, l := range g.Inbound(rp.Page) {
+			rp.Inbound = append(rp.Inbound, reportLink{Page: pages[l.From], Text: l.Text})
+		}
+		for _, l := range g.Outbound(rp.Page) {
+			rp.Outbound = append(rp.Outbound, reportLink{Page: pages[l.To], Text: l.Text})
+		}
+	}
+	for _, l := range g.Links {
+		if broken(l.To) {
+			data.Broken = append(data.Broken, l)
+		}
+	}
+	for _, p := range g.Pages {
+		data.Malformed = append(data.Malformed, p.Diagnostics...)
+	}
+
+	if g.Root != nil {
+		data.Root = g.Root.URL
+		// breadth first, so that pages hang under a page closest to the root
+		data.Tree = &reportNode{Page: pages[g.Root]}
+		seen := map[*Page]bool{g.Root: true}
+		queue := []*reportNode{data.Tree}
+		for len(queue) > 0 {
+			n := queue[0]
+			queue = queue[1:]
+			for _, l := range n.Page.Outbound {
+				if seen[l.Page.Page] {
+					continue
+				}
+				seen[l.Page.Page] = true
+				child := &reportNode{Page: l.Page}
+				n.Children = append(n.Children, child)
+				queue = append(queue, child)
+			}
+		}
+	}
+
+	return reportTemplate.Execute(w, data)
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"ms": func(d time.Duration) string {
+		return fmt.Sprintf("%.0f", float64(d)/float64(time.Millisecond))
+	},
+	"broken": broken,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Crawl report for {{.Root}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #ddd; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; cursor: pointer; user-select: none; }
+th.asc::after { content: " \25B2"; }
+th.desc::after { content: " \25BC"; }
+tr.broken td { background: #fdecea; }
+input[type=search] { width: 100%; padding: 6px; margin-bottom: 8px; box-sizing: border-box; }
+details > details, details > div.leaf { margin-left: 1.5em; }
+summary, div.leaf { padding: 2px 0; }
+.muted { color: #888; }
+</style>
+</head>
+<body>
+<h1>Crawl report for {{.Root}}</h1>
+<p>{{len .Pages}} pages, {{.Links}} links, {{len .Broken}} broken links, {{len .Malformed}} malformed links. Generated {{.Generated.Format "2006-01-02 15:04:05 MST"}}.</p>
+
+<h2>Pages</h2>
+<input type="search" id="search" placeholder="Search pages">
+<table id="pages">
+<thead><tr><th>URL</th><th>Status</th><th data-type="number">Depth</th><th>Title</th><th>Content type</th><th data-type="number">Inbound</th><th data-type="number">Outbound</th><th data-type="number">Response ms</th></tr></thead>
+<tbody>
+{{range .Pages}}<tr{{if broken .Page}} class="broken"{{end}}><td><a href="#{{.ID}}">{{.URL}}</a></td><td>{{.Status}}</td><td>{{.Depth}}</td><td>{{.Title}}</td><td>{{.ContentType}}</td><td>{{len .Inbound}}</td><td>{{len .Outbound}}</td><td>{{ms .ResponseTime}}</td></tr>
+{{end}}</tbody>
+</table>
+
+<h2>Broken links</h2>
+{{if .Broken}}<table>
+<thead><tr><th>From</th><th>To</th><th>Text</th><th>Status</th></tr></thead>
+<tbody>
+{{range .Broken}}<tr><td>{{.From.URL}}</td><td>{{.To.URL}}</td><td>{{.Text}}</td><td>{{if .To.Err}}{{.To.Err}}{{else}}{{.To.StatusCode}}{{end}}</td></tr>
+{{end}}</tbody>
+</table>{{else}}<p class="muted">None</p>{{end}}
+
+<h2>Malformed links</h2>
+{{if .Malformed}}<table>
+<thead><tr><th>Page</th><th>Link</th><th>Element</th><th>Reason</th></tr></thead>
+<tbody>
+{{range .Malformed}}<tr class="broken"><td>{{.Source}}</td><td><code>{{.Raw}}</code></td><td>&lt;{{.Element}} {{.Attr}}&gt;</td><td>{{.Reason}}</td></tr>
+{{end}}</tbody>
+</table>{{else}}<p class="muted">None</p>{{end}}
+
+<h2>Site tree</h2>
+{{with .Tree}}{{template "node" .}}{{end}}
+
+<h2>Page details</h2>
+{{range .Pages}}<details id="{{.ID}}">
+<summary>{{.URL}} <span class="muted">{{.Status}}</span></summary>
+<p>Inbound links</p>
+{{if .Inbound}}<ul>{{range .Inbound}}<li><a href="#{{.Page.ID}}">{{.Page.URL}}</a> <span class="muted">{{.Text}}</span></li>{{end}}</ul>{{else}}<p class="muted">None</p>{{end}}
+<p>Outbound links</p>
+{{if .Outbound}}<ul>{{range .Outbound}}<li><a href="#{{.Page.ID}}">{{.Page.URL}}</a> <span class="muted">{{.Text}}</span></li>{{end}}</ul>{{else}}<p class="muted">None</p>{{end}}
+{{if .Diagnostics}}<p>Malformed links</p>
+<ul>{{range .Diagnostics}}<li><code>{{.Raw}}</code> <span class="muted">{{.Reason}}</span></li>{{end}}</ul>{{end}}
+<p><a href="{{.URL}}">Open page</a></p>
+</details>
+{{end}}
+
+<script>
+(function() {
+  var table = document.getElementById("pages");
+  var tbody = table.tBodies[0];
+  document.getElementById("search").addEventListener("input", function() {
+    var q = this.value.toLowerCase();
+    Array.prototype.forEach.call(tbody.rows, function(row) {
+      row.style.display = row.textContent.toLowerCase().indexOf(q) >= 0 ? "" : "none";
+    });
+  });
+  Array.prototype.forEach.call(table.tHead.rows[0].cells, function(th, i) {
+    th.addEventListener("click", function() {
+      var asc = !th.classList.contains("asc");
+      Array.prototype.forEach.call(th.parentNode.cells, function(c) { c.classList.remove("asc", "desc"); });
+      th.classList.add(asc ? "asc" : "desc");
+      var number = th.getAttribute("data-type") === "number";
+      var rows = Array.prototype.slice.call(tbody.rows);
+      rows.sort(function(a, b) {
+        var x = a.cells[i].textContent, y = b.cells[i].textContent;
+        var c = number ? parseFloat(x) - parseFloat(y) : x.localeCompare(y);
+        return asc ? c : -c;
+      });
+      rows.forEach(function(row) { tbody.appendChild(row); });
+    });
+  });
+  // open the details of a page when following a link to it
+  function openTarget() {
+    var el = document.getElementById(location.hash.slice(1));
+    if (el && el.tagName === "DETAILS") el.open = true;
+  }
+  window.addEventListener("hashchange", openTarget);
+  openTarget();
+})();
+</script>
+</body>
+</html>
+{{define "node"}}{{if .Children}}<details{{if eq .Page.Depth 0}} open{{end}}>
+<summary><a href="#{{.Page.ID}}">{{.Page.URL}}</a> <span class="muted">{{.Page.Status}}</span></summary>
+{{range .Children}}{{template "node" .}}{{end}}</details>
+{{else}}<div class="leaf"><a href="#{{.Page.ID}}">{{.Page.URL}}</a> <span class="muted">{{.Page.Status}}</span></div>
+{{end}}{{end}}`))
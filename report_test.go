@@ -0,0 +1,36 @@
+package crawler
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteHTMLReport(t *testing.T) {
+	g := testDiagramGraph()
+	g.Root.Title = "Home <&>"
+	g.Root.Diagnostics = []Diagnostic{{Source: g.Root.URL, Raw: "http://[::1", Element: "a", Attr: "href", Reason: "missing ']' in host"}}
+	var buf bytes.Buffer
+	if err := WriteHTMLReport(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, expect := range []string{
+		"4 pages, 7 links, 3 broken links, 1 malformed links.",
+		`<td><code>http://[::1</code></td>`,
+		`<td>Home &lt;&amp;&gt;</td>`,
+		`<tr class="broken"><td><a href="#p2">http://example.com/blog/post</a></td><td>404</td>`,
+		`<details id="p1">`,
+	} {
+		if !strings.Contains(out, expect) {
+			t.Errorf("expect report to contain %s", expect)
+		}
+	}
+	if strings.Contains(out, "<link") || strings.Contains(out, "src=") {
+		t.Error("expect report to have no external assets")
+	}
+	// every page is in the tree once
+	if n := strings.Count(out, `<summary><a href="#p1">`); n != 1 {
+		t.Errorf("expect /blog once in the tree, got %d", n)
+	}
+}
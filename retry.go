@@ -0,0 +1,136 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Defaults for retrying transient failures
+const (
+	DefaultRetries    = 2
+	DefaultBackoff    = 500 * time.Millisecond
+	DefaultMaxBackoff = 30 * time.Second
+)
+
+// retryable reports whether a response with status is worth retrying
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// transient reports whether a fetch error is worth retrying: timeouts and
+// connections dropped or refused. Errors such as bad certificates, too many
+// redirects or unsupported schemes fail the same way every time
+func transient(err error) bool {
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+	for _, errno := range []syscall.Errno{syscall.ECONNRESET, syscall.ECONNREFUSED, syscall.ECONNABORTED, syscall.EPIPE} {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// backoff returns the wait before retry number attempt (starting at 0): an
+// exponentially growing duration capped at max, with jitter in its upper half
+func backoff(base, max time.Duration, attempt int) time.Duration {
+	d := base
+	for i := 0; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	if d <= 1 {
+		return d
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)))
+}
+
+// retryAfter parses a Retry-After header, given either in seconds or as an
+// http date
+func retryAfter(h http.Header, now time.Time) (time.Duration, bool) {
+	v := strings.TrimSpace(h.Get("Retry-After"))
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	t, err := http.ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	if d := t.Sub(now); d > 0 {
+		return d, true
+	}
+	return 0, true
+}
+
+// get fetches u, retrying transient network errors, 429 and 5xx responses
+// up to r.retries times. A Retry-After header holds back all requests to the
+// host; if it asks for a longer wait than the maximum backoff, the response
+// is returned as is
+func (r *run) get(ctx context.Context, u string) (*Response, timing, error) {
+	pu, err := url.Parse(u)
+	if err != nil {
+		return nil, timing{}, err
+	}
+	for attempt := 0; ; attempt++ {
+		resp, t, err := r.fetch(ctx, u)
+		if ctx.Err() != nil {
+			if err == nil {
+				resp.Body.Close()
+			}
+			return nil, t, ctx.Err()
+		}
+		if attempt >= r.retries || err == nil && !retryable(resp.StatusCode) || err != nil && !transient(err) {
+			return resp, t, err
+		}
+
+		wait := backoff(r.backoff, r.maxBackoff, attempt)
+		held := false // the host's throttle does the waiting
+		if err == nil {
+			if d, ok := retryAfter(resp.Header, time.Now()); ok {
+				if d > r.maxBackoff {
+					return resp, t, nil
+				}
+				r.hosts.holdUntil(pu.Host, time.Now().Add(d))
+				wait, held = d, true
+			}
+			resp.Body.Close()
+			r.logf("!!!server returned %d for %s, retrying in %v\n", resp.StatusCode, u, wait)
+		} else {
+			r.logf("!!!failed to fetch %s, retrying in %v: %v\n", u, wait, err)
+		}
+
+		if held {
+			continue
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, t, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package crawler
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		wait  time.Duration
+		ok    bool
+	}{
+		{"", 0, false},
+		{"3", 3 * time.Second, true},
+		{"Mon, 01 Jan 2018 00:00:10 GMT", 10 * time.Second, true},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		if tt.value != "" {
+			h.Set("Retry-After", tt.value)
+		}
+		wait, ok := retryAfter(h, now)
+		if wait != tt.wait || ok != tt.ok {
+			t.Errorf("%q: expect %v %v, got %v %v", tt.value, tt.wait, tt.ok, wait, ok)
+		}
+	}
+}
+
+func TestCrawlRetries(t *testing.T) {
+	f := fixtures{
+		"http://example.com/":       htmlHome,
+		"http://example.com/about":  htmlAbout,
+		"http://example.com/career": htmlCareer,
+	}
+	// every page fails once: /about with a network error, the others with a
+	// 503
+	var mu sync.Mutex
+	failed := make(map[string]bool)
+	fetcher := FetcherFunc(func(ctx context.Context, url string) (*Response, error) {
+		mu.Lock()
+		first := !failed[url]
+		failed[url] = true
+		mu.Unlock()
+		if first && strings.HasSuffix(url, "/about") {
+			return nil, &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+		}
+		if first {
+			return &Response{
+				URL:        url,
+				StatusCode: http.StatusServiceUnavailable,
+				Header:     http.Header{"Retry-After": {"0"}},
+				Body:       ioutil.NopCloser(strings.NewReader("")),
+			}, nil
+		}
+		return f.Fetch(ctx, url)
+	})
+
+	c := New(WithFetcher(fetcher), WithBackoff(time.Millisecond, 10*time.Millisecond))
+	g, err := c.Crawl("http://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Pages) != 4 {
+		t.Fatalf("expect 4 pages got %d", len(g.Pages))
+	}
+	about := g.Page("http://example.com/about")
+	if about.StatusCode != http.StatusOK || len(g.Outbound(about)) != 2 {
+		t.Errorf("expect about page to have 2 links got %d", len(g.Outbound(about)))
+	}
+}
+
+func TestTransient(t *testing.T) {
+	urlErr := func(err error) error {
+		return &url.Error{Op: "Get", URL: "http://example.com/", Err: err}
+	}
+	tests := []struct {
+		err    error
+		expect bool
+	}{
+		{urlErr(&net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}), true},
+		{urlErr(&net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}), true},
+		{urlErr(io.ErrUnexpectedEOF), true},
+		{urlErr(&net.DNSError{Err: "timeout", Name: "example.com", IsTimeout: true}), true},
+		{urlErr(&net.DNSError{Err: "no such host", Name: "example.com", IsNotFound: true}), false},
+		{urlErr(x509.UnknownAuthorityError{}), false},
+		{urlErr(errors.New("stopped after 10 redirects")), false},
+		{urlErr(fmt.Errorf("unsupported protocol scheme %q", "ftp")), false},
+	}
+	for _, tt := range tests {
+		if got := transient(tt.err); got != tt.expect {
+			t.Errorf("%v: expect transient to be %v, got %v", tt.err, tt.expect, got)
+		}
+	}
+}
+
+func TestCrawlPermanentError(t *testing.T) {
+	var mu sync.Mutex
+	fetches := 0
+	fetcher := FetcherFunc(func(ctx context.Context, url string) (*Response, error) {
+		mu.Lock()
+		fetches++
+		mu.Unlock()
+		return nil, errors.New("stopped after 10 redirects")
+	})
+	c := New(WithFetcher(fetcher), WithIgnoreRobots(true), WithBackoff(time.Millisecond, 10*time.Millisecond))
+	if _, err := c.Crawl("http://example.com"); err == nil {
+		t.Fatal("expect the crawl to fail")
+	}
+	if fetches != 1 {
+		t.Errorf("expect a permanent error not to be retried, got %d fetches", fetches)
+	}
+}
+
+func TestCrawlRetryAfter(t *testing.T) {
+	var mu sync.Mutex
+	var limited, retried time.Time
+	fetcher := FetcherFunc(func(ctx context.Context, url string) (*Response, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if limited.IsZero() {
+			limited = time.Now()
+			return &Response{
+				URL:        url,
+				StatusCode: http.StatusTooManyRequests,
+				Header:     http.Header{"Retry-After": {"1"}},
+				Body:       ioutil.NopCloser(strings.NewReader("")),
+			}, nil
+		}
+		retried = time.Now()
+		return fixtures{"http://example.com/": htmlCareer}.Fetch(ctx, url)
+	})
+	c := New(WithFetcher(fetcher), WithIgnoreRobots(true))
+	g, err := c.Crawl("http://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Root.StatusCode != http.StatusOK {
+		t.Errorf("expect the retry to succeed, got %d", g.Root.StatusCode)
+	}
+	if d := retried.Sub(limited); d < 900*time.Millisecond {
+		t.Errorf("expect the retry to wait for Retry-After, got %v", d)
+	}
+}
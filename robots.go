@@ -0,0 +1,244 @@
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultUserAgent is the user agent sent with requests and matched against
+// robots.txt groups
+const DefaultUserAgent = "crawler (+https://github.com/jackielii/crawler)"
+
+// robots holds the robots.txt rules that apply to one user agent
+type robots struct {
+	rules []robotsRule
+	delay time.Duration // Crawl-delay, zero if not given
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// allowAll and disallowAll are used when robots.txt can't be used as is
+var (
+	allowAll    = &robots{}
+	disallowAll = &robots{rules: []robotsRule{{allow: false, pattern: "/"}}}
+)
+
+type robotsGroup struct {
+	agents []string
+	robots robots
+}
+
+// parseRobots reads a robots.txt from r and returns the rules of the group
+// that best matches userAgent, falling back to the "*" group
+func parseRobots(r io.Reader, userAgent string) (*robots, error) {
+	var groups []*robotsGroup
+	var cur *robotsGroup
+	inAgents := false // whether we are still reading a group's user-agent lines
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := s.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		i := strings.Index(line, ":")
+		if i < 0 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:i]))
+		value := strings.TrimSpace(line[i+1:])
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				cur = &robotsGroup{}
+				groups = append(groups, cur)
+				inAgents = true
+			}
+			cur.agents = append(cur.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			inAgents = false
+			if cur == nil {
+				continue
+			}
+			if value == "" {
+				// an empty Disallow allows everything
+				continue
+			}
+			cur.robots.rules = append(cur.robots.rules, robotsRule{allow: key == "allow", pattern: value})
+		case "crawl-delay":
+			inAgents = false
+			if cur == nil {
+				continue
+			}
+			secs, err := strconv.ParseFloat(value, 64)
+			if err != nil || secs < 0 {
+				continue
+			}
+			cur.robots.delay = time.Duration(secs * float64(time.Second))
+		default:
+			// e.g. Sitemap: doesn't belong to a group
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	// the product token is the agent name before any version or comment
+	token := strings.ToLower(userAgent)
+	if i := strings.IndexAny(token, "/ "); i >= 0 {
+		token = token[:i]
+	}
+
+	var best *robotsGroup
+	bestLen := -1
+	for _, g := range groups {
+		for _, a := range g.agents {
+			n := -1
+			if a == "*" {
+				n = 0
+			} else if a != "" && strings.HasPrefix(token, a) {
+				n = len(a)
+			}
+			if n > bestLen {
+				best, bestLen = g, n
+			}
+		}
+	}
+	if best == nil {
+		return allowAll, nil
+	}
+	return &best.robots, nil
+}
+
+// allowed reports whether u may be crawled. The longest matching rule wins,
+// Allow wins a tie
+func (r *robots) allowed(u *url.URL) bool {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+
+	allow := true
+	matched := -1
+	for _, rule := range r.rules {
+		if !matchRobots(rule.pattern, path) {
+			continue
+		}
+		if n := len(rule.pattern); n > matched || n == matched && rule.allow {
+			allow, matched = rule.allow, n
+		}
+	}
+	return allow
+}
+
+// matchRobots matches path against a robots.txt pattern in which * matches
+// any sequence of characters and a trailing $ anchors the end of the path
+func matchRobots(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = pattern[:len(pattern)-1]
+	}
+	parts := strings.Split(pattern, "*")
+
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	path = path[len(parts[0]):]
+	for i, part := range parts[1:] {
+		if i == len(parts)-2 && anchored {
+			return strings.HasSuffix(path, part)
+		}
+		j := strings.Index(path, part)
+		if j < 0 {
+			return false
+		}
+		path = path[j+len(part):]
+	}
+	return !anchored || path == ""
+}
+
+// hostRobots is the robots.txt of one host, fetched once
+type hostRobots struct {
+	ready  chan struct{} // closed once robots or err is set
+	robots *robots
+	err    error
+}
+
+// robotsFor returns the robots.txt rules for the host of u. Each host's
+// robots.txt is fetched the first time a url on it is asked about, and its
+// Crawl-delay applied to requests to the host. It returns an error only if
+// ctx is done
+func (r *run) robotsFor(ctx context.Context, u *url.URL) (*robots, error) {
+	if r.ignoreRobots {
+		return allowAll, nil
+	}
+	key := u.Scheme + "://" + u.Host
+	r.robotsLock.Lock()
+	hr := r.robots[key]
+	if hr == nil {
+		hr = &hostRobots{ready: make(chan struct{})}
+		r.robots[key] = hr
+		r.robotsLock.Unlock()
+
+		hr.robots, hr.err = r.fetchRobots(ctx, &url.URL{Scheme: u.Scheme, Host: u.Host})
+		if hr.err == nil {
+			r.hosts.setMinDelay(u.Host, hr.robots.delay)
+		}
+		close(hr.ready)
+	} else {
+		r.robotsLock.Unlock()
+	}
+
+	select {
+	case <-hr.ready:
+		return hr.robots, hr.err
+	default:
+	}
+	select {
+	case <-hr.ready:
+		return hr.robots, hr.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetchRobots fetches and parses /robots.txt of the site at root. A missing
+// robots.txt allows everything; an unreachable one disallows everything
+func (r *run) fetchRobots(ctx context.Context, root *url.URL) (*robots, error) {
+	u := root.ResolveReference(&url.URL{Path: "/robots.txt"}).String()
+	resp, _, err := r.get(ctx, u)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		r.logf("!!!unable to fetch %s, assuming everything is disallowed: %v\n", u, err)
+		return disallowAll, nil
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		r.logf("!!!server returned %d for %s, assuming everything is disallowed\n", resp.StatusCode, u)
+		return disallowAll, nil
+	case resp.StatusCode >= 400:
+		return allowAll, nil
+	}
+	rb, err := parseRobots(resp.Body, r.userAgent)
+	if err != nil {
+		r.logf("!!!unable to read %s, assuming everything is allowed: %v\n", u, err)
+		return allowAll, nil
+	}
+	return rb, nil
+}
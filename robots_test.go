@@ -0,0 +1,185 @@
+package crawler
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const robotsTxt = `
+# comment
+User-agent: *
+Disallow: /private/
+Allow: /private/public
+Disallow: /*.pdf$
+
+User-agent: Crawler
+User-agent: other
+Disallow: /search?
+Disallow: /tmp
+Allow: /tmp/ok
+Crawl-delay: 1.5
+
+Sitemap: https://example.com/sitemap.xml
+`
+
+func TestRobots(t *testing.T) {
+	tests := []struct {
+		agent   string
+		path    string
+		allowed bool
+	}{
+		{"somebot/1.0", "/", true},
+		{"somebot/1.0", "/private/x", false},
+		{"somebot/1.0", "/private/public/x", true},
+		{"somebot/1.0", "/docs/a.pdf", false},
+		{"somebot/1.0", "/docs/a.pdf?x=1", true},
+		{DefaultUserAgent, "/private/x", true},
+		{DefaultUserAgent, "/search?q=a", false},
+		{DefaultUserAgent, "/search", true},
+		{DefaultUserAgent, "/tmp/x", false},
+		{DefaultUserAgent, "/tmp/ok", true},
+	}
+	for _, tt := range tests {
+		rb, err := parseRobots(strings.NewReader(robotsTxt), tt.agent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		u, err := url.Parse("http://example.com" + tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := rb.allowed(u); got != tt.allowed {
+			t.Errorf("%s %s: expect allowed to be %v, got %v", tt.agent, tt.path, tt.allowed, got)
+		}
+	}
+
+	rb, err := parseRobots(strings.NewReader(robotsTxt), DefaultUserAgent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rb.delay != 1500*time.Millisecond {
+		t.Errorf("expect crawl delay to be 1.5s, got %v", rb.delay)
+	}
+}
+
+func TestCrawlRobots(t *testing.T) {
+	f := fixtures{
+		"http://example.com/robots.txt": "User-agent: *\nDisallow: /about\n",
+		"http://example.com/":           htmlHome,
+		"http://example.com/about":      htmlAbout,
+	}
+	for _, ignore := range []bool{false, true} {
+		g, err := New(WithFetcher(f), WithIgnoreRobots(ignore)).Crawl("http://example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		about := g.Page("http://example.com/about")
+		if about == nil {
+			t.Fatal("expect /about to be in the links")
+		}
+		if blocked := about.NotCrawled != ""; blocked == ignore {
+			t.Errorf("ignore robots %v: expect /about blocked to be %v", ignore, !ignore)
+		}
+	}
+}
+
+func TestCrawlRobotsPerHost(t *testing.T) {
+	f := fixtures{
+		"http://example.com/robots.txt":      "User-agent: *\nDisallow: /about\n",
+		"http://example.com/":                `<a href="/private">private</a><a href="/about">about</a><a href="http://help.example.com/">help</a>`,
+		"http://example.com/private":         htmlCareer,
+		"http://help.example.com/robots.txt": "User-agent: *\nDisallow: /private\nCrawl-delay: 0.2\n",
+		"http://help.example.com/":           `<a href="/private">private</a><a href="/about">about</a><a href="/faq">faq</a>`,
+		"http://help.example.com/about":      htmlCareer,
+		"http://help.example.com/faq":        htmlCareer,
+	}
+	var mu sync.Mutex
+	fetched := make(map[string]time.Time)
+	fetcher := FetcherFunc(func(ctx context.Context, url string) (*Response, error) {
+		mu.Lock()
+		fetched[url] = time.Now()
+		mu.Unlock()
+		return f.Fetch(ctx, url)
+	})
+	inScope, err := Scope{Subdomains: true}.Func()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := New(WithFetcher(fetcher), WithScope(inScope)).Crawl("http://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for u, blocked := range map[string]bool{
+		"http://example.com/private":      false,
+		"http://example.com/about":        true,
+		"http://help.example.com/private": true,
+		"http://help.example.com/about":   false,
+	} {
+		p := g.Page(u)
+		if p == nil {
+			t.Fatalf("expect %s to be in the links", u)
+		}
+		if (p.NotCrawled == "disallowed by robots.txt") != blocked {
+			t.Errorf("expect %s blocked to be %v, got %q", u, blocked, p.NotCrawled)
+		}
+		if _, ok := fetched[u]; ok == blocked {
+			t.Errorf("expect %s fetched to be %v", u, !blocked)
+		}
+	}
+
+	// the help host's Crawl-delay applies to its own requests
+	gap := fetched["http://help.example.com/faq"].Sub(fetched["http://help.example.com/about"])
+	if gap < 0 {
+		gap = -gap
+	}
+	if gap < 150*time.Millisecond {
+		t.Errorf("expect requests to help.example.com to be 200ms apart, got %v", gap)
+	}
+}
+
+func TestCrawlRobotsOutsideLevelLock(t *testing.T) {
+	f := fixtures{
+		"http://example.com/":                `<a href="/a">a</a><a href="/b">b</a><a href="/c">c</a>`,
+		"http://example.com/a":               `<a href="http://help.example.com/">help</a>`,
+		"http://example.com/b":               htmlCareer,
+		"http://example.com/c":               htmlCareer,
+		"http://help.example.com/":           htmlCareer,
+		"http://help.example.com/robots.txt": "User-agent: *\nDisallow:\n",
+	}
+	fetchedC := make(chan struct{})
+	var waited time.Duration
+	fetcher := FetcherFunc(func(ctx context.Context, url string) (*Response, error) {
+		switch url {
+		case "http://example.com/c":
+			close(fetchedC)
+		case "http://help.example.com/robots.txt":
+			// the other worker has to get past /b to fetch /c
+			start := time.Now()
+			select {
+			case <-fetchedC:
+			case <-time.After(2 * time.Second):
+			}
+			waited = time.Since(start)
+		}
+		return f.Fetch(ctx, url)
+	})
+	inScope, err := Scope{Subdomains: true}.Func()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := New(WithFetcher(fetcher), WithScope(inScope), WithConcurrency(2)).Crawl("http://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if waited >= 2*time.Second {
+		t.Errorf("expect other pages to finish while robots.txt is fetched, waited %v", waited)
+	}
+	if p := g.Page("http://help.example.com/"); p == nil || p.StatusCode != 200 {
+		t.Errorf("expect help.example.com to be crawled, got %+v", p)
+	}
+}
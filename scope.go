@@ -0,0 +1,129 @@
+package crawler
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ScopeFunc reports whether link should be crawled when crawling from seed.
+// link is normalised
+type ScopeFunc func(seed, link *url.URL) bool
+
+// SameHost is the default ScopeFunc: only links on the seed's host are in scope
+func SameHost(seed, link *url.URL) bool {
+	return strings.EqualFold(link.Hostname(), seed.Hostname())
+}
+
+// Scope is a policy for which links are crawled. The zero Scope is SameHost
+type Scope struct {
+	// Subdomains includes the subdomains of the seed's host and of Hosts.
+	// A leading www. is ignored, so crawling www.monzo.com or monzo.com
+	// both cover monzo.com, www.monzo.com and community.monzo.com
+	Subdomains bool
+	Hosts      []string // hosts crawled as well as the seed's, e.g. help.monzo.com
+
+	// PathPrefixes, if not empty, only includes urls whose path starts with
+	// one of them, e.g. /blog/
+	PathPrefixes []string
+
+	// Include, if not empty, only includes urls matching one of these
+	// patterns, and Exclude leaves out urls matching any of them. Patterns
+	// are matched against the whole normalised url. A pattern starting with
+	// re: is a regular expression, any other is a glob in which * matches
+	// any characters, including /, e.g. https://monzo.com/blog/*?page=*
+	Include []string
+	Exclude []string
+}
+
+// Func returns the ScopeFunc applying s, or an error if a pattern is invalid
+func (s Scope) Func() (ScopeFunc, error) {
+	include, err := compilePatterns(s.Include)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := compilePatterns(s.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	return func(seed, link *url.URL) bool {
+		if !s.host(seed, link) {
+			return false
+		}
+		if len(s.PathPrefixes) > 0 && !hasAnyPrefix(link.Path, s.PathPrefixes) {
+			return false
+		}
+		l := link.String()
+		if len(include) > 0 && !matchAnyPattern(include, l) {
+			return false
+		}
+		return !matchAnyPattern(exclude, l)
+	}, nil
+}
+
+// host reports whether link is on a host in scope
+func (s Scope) host(seed, link *url.URL) bool {
+	host := strings.ToLower(link.Hostname())
+	for _, h := range append([]string{seed.Hostname()}, s.Hosts...) {
+		h = strings.ToLower(h)
+		if host == h {
+			return true
+		}
+		if s.Subdomains {
+			h = strings.TrimPrefix(h, "www.")
+			if host == h || strings.HasSuffix(host, "."+h) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// compilePatterns compiles re: regular expressions and globs to regular
+// expressions
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, p := range patterns {
+		expr := strings.TrimPrefix(p, "re:")
+		if expr == p {
+			expr = globToRegexp(p)
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid scope pattern %q", p)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// globToRegexp turns a glob in which * matches any characters into a
+// regular expression matching the whole string. Other characters, including
+// the ? of queries, match themselves
+func globToRegexp(glob string) string {
+	parts := strings.Split(glob, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	return "^" + strings.Join(parts, ".*") + "$"
+}
+
+func matchAnyPattern(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
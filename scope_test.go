@@ -0,0 +1,72 @@
+package crawler
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestScope(t *testing.T) {
+	tests := []struct {
+		scope  Scope
+		seed   string
+		link   string
+		expect bool
+	}{
+		{Scope{}, "https://monzo.com/", "https://monzo.com/about", true},
+		{Scope{}, "https://monzo.com/", "https://www.monzo.com/about", false},
+		{Scope{}, "https://monzo.com/", "https://google.com/", false},
+		{Scope{Subdomains: true}, "https://monzo.com/", "https://www.monzo.com/about", true},
+		{Scope{Subdomains: true}, "https://www.monzo.com/", "https://monzo.com/about", true},
+		{Scope{Subdomains: true}, "https://www.monzo.com/", "https://community.monzo.com/", true},
+		{Scope{Subdomains: true}, "https://monzo.com/", "https://notmonzo.com/", false},
+		{Scope{Hosts: []string{"help.monzo.com"}}, "https://monzo.com/", "https://help.monzo.com/", true},
+		{Scope{Hosts: []string{"help.monzo.com"}}, "https://monzo.com/", "https://www.monzo.com/", false},
+		{Scope{PathPrefixes: []string{"/blog/"}}, "https://monzo.com/", "https://monzo.com/blog/post", true},
+		{Scope{PathPrefixes: []string{"/blog/"}}, "https://monzo.com/", "https://monzo.com/about", false},
+		{Scope{Include: []string{"https://monzo.com/blog/*"}}, "https://monzo.com/", "https://monzo.com/blog/a/b", true},
+		{Scope{Include: []string{"https://monzo.com/blog/*"}}, "https://monzo.com/", "https://monzo.com/about", false},
+		{Scope{Exclude: []string{"*?page=*"}}, "https://monzo.com/", "https://monzo.com/blog?page=2", false},
+		{Scope{Exclude: []string{"*?page=*"}}, "https://monzo.com/", "https://monzo.com/blog/page", true},
+		{Scope{Exclude: []string{`re:/\d{4}/\d{2}/`}}, "https://monzo.com/", "https://monzo.com/blog/2018/01/post", false},
+		{Scope{Exclude: []string{`re:/\d{4}/\d{2}/`}}, "https://monzo.com/", "https://monzo.com/blog/post", true},
+	}
+	for _, tt := range tests {
+		inScope, err := tt.scope.Func()
+		if err != nil {
+			t.Fatal(err)
+		}
+		seed, _ := url.Parse(tt.seed)
+		link, _ := url.Parse(tt.link)
+		if got := inScope(seed, link); got != tt.expect {
+			t.Errorf("%+v %s from %s: expect %v, got %v", tt.scope, tt.link, tt.seed, tt.expect, got)
+		}
+	}
+
+	if _, err := (Scope{Include: []string{"re:("}}).Func(); err == nil {
+		t.Error("expect an invalid regular expression to be an error")
+	}
+}
+
+func TestCrawlScope(t *testing.T) {
+	f := fixtures{
+		"http://example.com/":          `<a href="/blog/">blog</a><a href="/about">about</a>`,
+		"http://example.com/blog/":     `<a href="/blog/post?utm_source=blog">post</a><a href="/blog/?page=2">more</a>`,
+		"http://example.com/blog/post": htmlCareer,
+	}
+	inScope, err := Scope{
+		PathPrefixes: []string{"/blog/"},
+		Exclude:      []string{"*?*"},
+	}.Func()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// patterns are matched against the normalised url, without utm_source
+	normalizer := Normalizer{DropParams: []string{"utm_*"}}
+	g, err := New(WithFetcher(f), WithScope(inScope), WithNormalizer(normalizer)).Crawl("http://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Pages) != 3 || g.Page("http://example.com/blog/post") == nil {
+		t.Errorf("expect the root, /blog/ and /blog/post, got %d pages", len(g.Pages))
+	}
+}
@@ -0,0 +1,122 @@
+package crawler
+
+import (
+	"encoding/xml"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// MaxSitemapURLs is the most urls one sitemap may list, see
+// https://www.sitemaps.org/protocol.html
+const MaxSitemapURLs = 50000
+
+const sitemapXMLNS = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+type xmlURLSet struct {
+	XMLName xml.Name `xml:"urlset"`
+	XMLNS   string   `xml:"xmlns,attr"`
+	URLs    []xmlLoc `xml:"url"`
+}
+
+type xmlSitemapIndex struct {
+	XMLName  xml.Name `xml:"sitemapindex"`
+	XMLNS    string   `xml:"xmlns,attr"`
+	Sitemaps []xmlLoc `xml:"sitemap"`
+}
+
+type xmlLoc struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// SitemapPages returns the pages of g that belong in a sitemap: html pages
+// fetched with status 200 on the same scheme and host as the root page, in
+// the order they were found. They are split in chunks of at most
+// MaxSitemapURLs, one per sitemap file; more than one chunk needs a sitemap
+// index
+func SitemapPages(g *Graph) [][]*Page {
+	if g.Root == nil {
+		return nil
+	}
+	site, err := url.Parse(sitemapLoc(g.Root))
+	if err != nil {
+		return nil
+	}
+
+	var chunks [][]*Page
+	var chunk []*Page
+	seen := make(map[string]bool)
+	for _, p := range g.Pages {
+		if p.StatusCode != 200 || p.ContentType != "text/html" {
+			continue
+		}
+		// redirected pages are listed at where they ended up, which must be
+		// on the sitemap's own site
+		loc := sitemapLoc(p)
+		if seen[loc] || !sameSite(site, loc) {
+			continue
+		}
+		seen[loc] = true
+
+		if len(chunk) == MaxSitemapURLs {
+			chunks = append(chunks, chunk)
+			chunk = nil
+		}
+		chunk = append(chunk, p)
+	}
+	if chunk != nil {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// sameSite reports whether loc has the scheme and host of site
+func sameSite(site *url.URL, loc string) bool {
+	u, err := url.Parse(loc)
+	return err == nil && strings.EqualFold(u.Scheme, site.Scheme) && strings.EqualFold(u.Host, site.Host)
+}
+
+func sitemapLoc(p *Page) string {
+	if p.FinalURL != "" {
+		return p.FinalURL
+	}
+	return p.URL
+}
+
+// WriteSitemap writes a sitemap listing pages, with lastmod taken from their
+// Last-Modified header
+func WriteSitemap(w io.Writer, pages []*Page) error {
+	set := xmlURLSet{XMLNS: sitemapXMLNS}
+	for _, p := range pages {
+		loc := xmlLoc{Loc: sitemapLoc(p)}
+		if !p.LastModified.IsZero() {
+			loc.LastMod = p.LastModified.UTC().Format(time.RFC3339)
+		}
+		set.URLs = append(set.URLs, loc)
+	}
+	return writeXML(w, set)
+}
+
+// WriteSitemapIndex writes a sitemap index listing the sitemaps at locs
+func WriteSitemapIndex(w io.Writer, locs []string) error {
+	index := xmlSitemapIndex{XMLNS: sitemapXMLNS}
+	for _, loc := range locs {
+		index.Sitemaps = append(index.Sitemaps, xmlLoc{Loc: loc})
+	}
+	return writeXML(w, index)
+}
+
+func writeXML(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
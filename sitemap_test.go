@@ -0,0 +1,84 @@
+package crawler
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSitemap(t *testing.T) {
+	g := newGraph()
+	home, _ := g.add("http://example.com/")
+	home.StatusCode, home.ContentType = 200, "text/html"
+	home.LastModified = time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)
+	missing, _ := g.add("http://example.com/missing")
+	missing.StatusCode, missing.ContentType = 404, "text/html"
+	pdf, _ := g.add("http://example.com/a.pdf")
+	pdf.StatusCode, pdf.ContentType = 200, "application/pdf"
+	g.Root = home
+
+	chunks := SitemapPages(g)
+	if len(chunks) != 1 || len(chunks[0]) != 1 || chunks[0][0] != home {
+		t.Fatalf("expect only the home page in the sitemap, got %v", chunks)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSitemap(&buf, chunks[0]); err != nil {
+		t.Fatal(err)
+	}
+	expect := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>http://example.com/</loc>
+    <lastmod>2018-01-02T03:04:05Z</lastmod>
+  </url>
+</urlset>
+`
+	if buf.String() != expect {
+		t.Errorf("expect sitemap\n%s\ngot\n%s", expect, buf.String())
+	}
+}
+
+func TestSitemapSplit(t *testing.T) {
+	g := newGraph()
+	for i := 0; i < MaxSitemapURLs+1; i++ {
+		p, _ := g.add(fmt.Sprintf("http://example.com/%d", i))
+		p.StatusCode, p.ContentType = 200, "text/html"
+	}
+	g.Root = g.Pages[0]
+	chunks := SitemapPages(g)
+	if len(chunks) != 2 || len(chunks[0]) != MaxSitemapURLs || len(chunks[1]) != 1 {
+		t.Fatalf("expect sitemaps of %d and 1 urls, got %d chunks", MaxSitemapURLs, len(chunks))
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSitemapIndex(&buf, []string{"http://example.com/sitemap-1.xml", "http://example.com/sitemap-2.xml"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "<sitemapindex") || strings.Count(buf.String(), "<sitemap>") != 2 {
+		t.Errorf("expect an index of 2 sitemaps, got\n%s", buf.String())
+	}
+}
+
+func TestSitemapOtherSites(t *testing.T) {
+	g := newGraph()
+	home, _ := g.add("http://example.com/")
+	home.StatusCode, home.ContentType, home.FinalURL = 200, "text/html", "https://example.com/"
+	g.Root = home
+	about, _ := g.add("http://example.com/about")
+	about.StatusCode, about.ContentType, about.FinalURL = 200, "text/html", "https://example.com/about"
+	// an in scope link redirecting off site
+	app, _ := g.add("http://example.com/app")
+	app.StatusCode, app.ContentType, app.FinalURL = 200, "text/html", "https://apps.apple.com/app/monzo"
+	help, _ := g.add("http://help.example.com/")
+	help.StatusCode, help.ContentType = 200, "text/html"
+	insecure, _ := g.add("http://example.com/old")
+	insecure.StatusCode, insecure.ContentType = 200, "text/html"
+
+	chunks := SitemapPages(g)
+	if len(chunks) != 1 || len(chunks[0]) != 2 || chunks[0][0] != home || chunks[0][1] != about {
+		t.Fatalf("expect only the pages on https://example.com, got %v", chunks)
+	}
+}
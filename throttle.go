@@ -0,0 +1,121 @@
+package crawler
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// throttle spaces out requests so that they start at least delay apart
+type throttle struct {
+	mu    sync.Mutex
+	delay time.Duration
+	next  time.Time // earliest start of the next request
+}
+
+// wait blocks until the caller's turn to make a request or ctx is done
+func (t *throttle) wait(ctx context.Context) error {
+	t.mu.Lock()
+	now := time.Now()
+	at := t.next
+	if at.Before(now) {
+		at = now
+	}
+	t.next = at.Add(t.delay)
+	t.mu.Unlock()
+
+	d := at.Sub(now)
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// hostLimits applies the per host politeness limits
+type hostLimits struct {
+	interval    time.Duration // minimum time between request starts
+	concurrency int           // maximum concurrent requests, 0 for no limit
+
+	mu    sync.Mutex
+	hosts map[string]*hostLimit
+}
+
+type hostLimit struct {
+	throttle
+	slots chan struct{} // nil if there's no concurrency limit
+}
+
+func newHostLimits(rps float64, delay time.Duration, concurrency int) *hostLimits {
+	interval := delay
+	if rps > 0 {
+		if d := time.Duration(float64(time.Second) / rps); d > interval {
+			interval = d
+		}
+	}
+	return &hostLimits{
+		interval:    interval,
+		concurrency: concurrency,
+		hosts:       make(map[string]*hostLimit),
+	}
+}
+
+func (h *hostLimits) get(host string) *hostLimit {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	l := h.hosts[host]
+	if l == nil {
+		l = &hostLimit{throttle: throttle{delay: h.interval}}
+		if h.concurrency > 0 {
+			l.slots = make(chan struct{}, h.concurrency)
+		}
+		h.hosts[host] = l
+	}
+	return l
+}
+
+// setMinDelay makes requests to host at least d apart, e.g. for Crawl-delay
+func (h *hostLimits) setMinDelay(host string, d time.Duration) {
+	l := h.get(host)
+	l.mu.Lock()
+	if d > l.delay {
+		l.delay = d
+	}
+	l.mu.Unlock()
+}
+
+// holdUntil makes no request to host start before t, e.g. for Retry-After
+func (h *hostLimits) holdUntil(host string, t time.Time) {
+	l := h.get(host)
+	l.mu.Lock()
+	if t.After(l.next) {
+		l.next = t
+	}
+	l.mu.Unlock()
+}
+
+// acquire waits until a request to host may start. release must be called
+// when the request is done
+func (h *hostLimits) acquire(ctx context.Context, host string) (release func(), err error) {
+	l := h.get(host)
+	release = func() {}
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+			release = func() { <-l.slots }
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if err := l.wait(ctx); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
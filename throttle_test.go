@@ -0,0 +1,78 @@
+package crawler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHostLimits(t *testing.T) {
+	const delay = 20 * time.Millisecond
+	f := fixtures{
+		"http://example.com/":       htmlHome,
+		"http://example.com/about":  htmlAbout,
+		"http://example.com/career": htmlCareer,
+	}
+
+	var mu sync.Mutex
+	var inFlight, maxInFlight int
+	var starts []time.Time
+	fetcher := FetcherFunc(func(ctx context.Context, url string) (*Response, error) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		starts = append(starts, time.Now())
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return f.Fetch(ctx, url)
+	})
+
+	c := New(WithFetcher(fetcher), WithDelay(delay), WithHostConcurrency(1))
+	if _, err := c.Crawl("http://example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	if maxInFlight != 1 {
+		t.Errorf("expect at most 1 request in flight, got %d", maxInFlight)
+	}
+	for i := 1; i < len(starts); i++ {
+		// allow some scheduling slack between the throttle and the fetch
+		if gap := starts[i].Sub(starts[i-1]); gap < delay*3/4 {
+			t.Errorf("expect requests at least %v apart, got %v", delay, gap)
+		}
+	}
+}
+
+func TestHostLimitsHoldUntil(t *testing.T) {
+	h := newHostLimits(0, 0, 0)
+	start := time.Now()
+	h.holdUntil("example.com", start.Add(100*time.Millisecond))
+
+	release, err := h.acquire(context.Background(), "other.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if d := time.Since(start); d > 50*time.Millisecond {
+		t.Errorf("expect other hosts not to be held, waited %v", d)
+	}
+
+	for i := 0; i < 2; i++ {
+		release, err := h.acquire(context.Background(), "example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+		if d := time.Since(start); d < 100*time.Millisecond {
+			t.Errorf("expect request %d to example.com to be held for 100ms, waited %v", i, d)
+		}
+	}
+}